@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/devshansharma/tools/crypt"
+)
+
+// Actor who performed an action
+type Actor struct {
+	Subject string `json:"subject,omitempty"`
+	Issuer  string `json:"issuer,omitempty"`
+	TokenID string `json:"token_id,omitempty"`
+	IP      string `json:"ip,omitempty"`
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor, used by Logger.Log
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorFromContext returns the actor set with WithActor, the zero Actor when none is set
+func ActorFromContext(ctx context.Context) Actor {
+	a, _ := ctx.Value(actorKey{}).(Actor)
+	return a
+}
+
+// ActorFromClaims returns the actor described by verified JWT claims
+func ActorFromClaims(claims jwt.MapClaims) Actor {
+	var a Actor
+	a.Subject, _ = claims["sub"].(string)
+	a.Issuer, _ = claims["iss"].(string)
+	a.TokenID, _ = claims["jti"].(string)
+
+	return a
+}
+
+// ActorFromRequest verifies the bearer token of r with crypt.ParseAndVerifyToken
+// and returns its actor, with the remote IP of r
+func ActorFromRequest(r *http.Request, publicKey *ecdsa.PublicKey, issuer, audience string) (Actor, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return Actor{IP: remoteIP(r)}, errors.New("missing bearer token")
+	}
+
+	claims, err := crypt.ParseAndVerifyToken(token, publicKey, issuer, audience)
+	if err != nil {
+		return Actor{IP: remoteIP(r)}, err
+	}
+
+	a := ActorFromClaims(claims)
+	a.IP = remoteIP(r)
+
+	return a, nil
+}
+
+// Middleware puts the actor of each request in its context, see
+// ActorFromRequest. Requests without a valid token get an actor with their
+// IP only, rejecting them is left to the authentication middleware.
+func Middleware(publicKey *ecdsa.PublicKey, issuer, audience string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			a, _ := ActorFromRequest(r, publicKey, issuer, audience)
+			next.ServeHTTP(w, r.WithContext(WithActor(r.Context(), a)))
+		})
+	}
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
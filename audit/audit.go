@@ -0,0 +1,226 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"time"
+)
+
+// Outcome of an audited action
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	OutcomeDenied  Outcome = "denied"
+)
+
+// Entry is one record of the audit trail. Seq, Time, PrevHash and Hash are
+// set by Logger.Log.
+type Entry struct {
+	Seq      uint64            `json:"seq"`
+	Time     time.Time         `json:"time"`
+	Actor    Actor             `json:"actor"`
+	Action   string            `json:"action"`
+	Resource string            `json:"resource"`
+	Outcome  Outcome           `json:"outcome"`
+	Details  map[string]string `json:"details,omitempty"`
+	PrevHash string            `json:"prev_hash"`
+	Hash     string            `json:"hash,omitempty"`
+}
+
+// Sink stores the entries, in order
+type Sink interface {
+	// Append stores e after the previous entries
+	Append(ctx context.Context, e Entry) error
+	// Last returns the last entry, nil when there is none
+	Last(ctx context.Context) (*Entry, error)
+	// Scan calls fn with every entry, in order
+	Scan(ctx context.Context, fn func(e Entry) error) error
+}
+
+// Option to configure Logger
+type Option func(l *Logger)
+
+// WithHMACKey for chaining entries with HMAC-SHA256 instead of SHA-256, so
+// that the trail can't be rewritten without the key
+func WithHMACKey(key []byte) Option {
+	return func(l *Logger) {
+		l.key = key
+	}
+}
+
+// Logger appends hash-chained entries to a sink: the hash of each entry
+// covers its content and the hash of the previous one, so modifying,
+// removing or reordering entries breaks the chain, see Verify. It is safe
+// for concurrent use, but only one Logger may write to a sink.
+type Logger struct {
+	sink Sink
+	key  []byte
+	now  func() time.Time
+
+	mu   sync.Mutex
+	last *Entry
+}
+
+// New returns a Logger continuing the chain stored in sink
+func New(ctx context.Context, sink Sink, opts ...Option) (*Logger, error) {
+	l := &Logger{
+		sink: sink,
+		now:  time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	last, err := sink.Last(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading last audit entry: %w", err)
+	}
+
+	l.last = last
+
+	return l, nil
+}
+
+// Log appends e to the trail and returns it as stored. The actor defaults to
+// the one in ctx, see WithActor.
+func (l *Logger) Log(ctx context.Context, e Entry) (Entry, error) {
+	if e.Action == "" {
+		return Entry{}, errors.New("audit entry without action")
+	}
+
+	if e.Actor == (Actor{}) {
+		e.Actor = ActorFromContext(ctx)
+	}
+
+	if e.Outcome == "" {
+		e.Outcome = OutcomeSuccess
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Seq = 1
+	e.PrevHash = ""
+	if l.last != nil {
+		e.Seq = l.last.Seq + 1
+		e.PrevHash = l.last.Hash
+	}
+	e.Time = l.now().UTC()
+
+	sum, err := Hash(e, l.key)
+	if err != nil {
+		return Entry{}, err
+	}
+	e.Hash = sum
+
+	if err := l.sink.Append(ctx, e); err != nil {
+		return Entry{}, fmt.Errorf("appending audit entry: %w", err)
+	}
+
+	l.last = &e
+
+	return e, nil
+}
+
+// Hash returns the hex encoded hash of the entry, without its Hash field:
+// SHA-256, or HMAC-SHA256 when key is set
+func Hash(e Entry, key []byte) (string, error) {
+	e.Hash = ""
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+
+	h.Write(data)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyError tells where the chain is broken
+type VerifyError struct {
+	Seq    uint64
+	Reason string
+}
+
+func (v *VerifyError) Error() string {
+	return fmt.Sprintf("audit trail broken at entry %d: %s", v.Seq, v.Reason)
+}
+
+// VerifyResult summarizes a valid trail
+type VerifyResult struct {
+	Entries int
+	// Head is the hash of the last entry. Keep it elsewhere to detect the
+	// removal of the last entries, which the chain alone can't.
+	Head string
+}
+
+// Verify checks the chain of the entries in sink, returning a *VerifyError
+// for the first entry that was modified, removed or reordered
+func Verify(ctx context.Context, sink Sink, key []byte) (VerifyResult, error) {
+	return verify(key, func(fn func(e Entry) error) error {
+		return sink.Scan(ctx, fn)
+	})
+}
+
+// VerifyReader is Verify for a trail in the format of FileSink, read from r,
+// e.g. a file opened read-only with os.Open
+func VerifyReader(ctx context.Context, r io.Reader, key []byte) (VerifyResult, error) {
+	return verify(key, func(fn func(e Entry) error) error {
+		return scanEntries(ctx, r, fn)
+	})
+}
+
+func verify(key []byte, scan func(fn func(e Entry) error) error) (VerifyResult, error) {
+	var res VerifyResult
+	var prev *Entry
+
+	err := scan(func(e Entry) error {
+		wantSeq, wantPrev := uint64(1), ""
+		if prev != nil {
+			wantSeq, wantPrev = prev.Seq+1, prev.Hash
+		}
+
+		switch {
+		case e.Seq != wantSeq:
+			return &VerifyError{Seq: e.Seq, Reason: fmt.Sprintf("expected entry %d", wantSeq)}
+		case e.PrevHash != wantPrev:
+			return &VerifyError{Seq: e.Seq, Reason: "previous hash mismatch"}
+		}
+
+		sum, err := Hash(e, key)
+		if err != nil {
+			return err
+		}
+
+		if !hmac.Equal([]byte(sum), []byte(e.Hash)) {
+			return &VerifyError{Seq: e.Seq, Reason: "hash mismatch"}
+		}
+
+		res.Entries++
+		res.Head = e.Hash
+		prev = &e
+
+		return nil
+	})
+
+	return res, err
+}
@@ -0,0 +1,202 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/audit"
+	"github.com/devshansharma/tools/crypt"
+)
+
+func TestLogger(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	key := []byte("audit-secret")
+
+	sink, err := audit.NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	log, err := audit.New(ctx, sink, audit.WithHMACKey(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx = audit.WithActor(ctx, audit.Actor{Subject: "user-1", IP: "10.0.0.1"})
+
+	first, err := log.Log(ctx, audit.Entry{Action: "invoice.create", Resource: "invoices/42"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), first.Seq)
+	assert.Equal(t, "user-1", first.Actor.Subject)
+	assert.Equal(t, audit.OutcomeSuccess, first.Outcome)
+
+	_, err = log.Log(ctx, audit.Entry{
+		Action:   "invoice.delete",
+		Resource: "invoices/42",
+		Outcome:  audit.OutcomeDenied,
+		Details:  map[string]string{"reason": "locked"},
+	})
+	assert.NoError(t, err)
+
+	_, err = log.Log(ctx, audit.Entry{})
+	assert.Error(t, err)
+
+	// a new logger continues the chain
+	log, err = audit.New(ctx, sink, audit.WithHMACKey(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, err := log.Log(ctx, audit.Entry{Action: "user.login", Resource: "users/1"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), third.Seq)
+
+	res, err := audit.Verify(ctx, sink, key)
+	assert.NoError(t, err)
+	assert.Equal(t, audit.VerifyResult{Entries: 3, Head: third.Hash}, res)
+
+	// a read-only copy of the trail verifies the same
+	assert.NoError(t, os.Chmod(path, 0o400))
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	res, err = audit.VerifyReader(ctx, f, key)
+	assert.NoError(t, err)
+	assert.Equal(t, audit.VerifyResult{Entries: 3, Head: third.Hash}, res)
+
+	// the hashes depend on the key
+	_, err = audit.Verify(ctx, sink, []byte("wrong"))
+	var verr *audit.VerifyError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, uint64(1), verr.Seq)
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	ctx := context.Background()
+
+	newTrail := func(t *testing.T) (string, [][]byte) {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		sink, err := audit.NewFileSink(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sink.Close()
+
+		log, err := audit.New(ctx, sink)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, action := range []string{"a", "b", "c"} {
+			if _, err := log.Log(ctx, audit.Entry{Action: action, Resource: "r"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return path, bytes.SplitAfter(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	}
+
+	for name, tc := range map[string]struct {
+		tamper func(lines [][]byte) [][]byte
+		seq    uint64
+	}{
+		"modified": {
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte(`"action":"b"`), []byte(`"action":"x"`), 1)
+				return lines
+			},
+			seq: 2,
+		},
+		"removed": {
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:1], lines[2:]...)
+			},
+			seq: 3,
+		},
+		"reordered": {
+			tamper: func(lines [][]byte) [][]byte {
+				lines[0], lines[1] = lines[1], lines[0]
+				return lines
+			},
+			seq: 2,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path, lines := newTrail(t)
+			lines = tc.tamper(lines)
+			if err := os.WriteFile(path, bytes.Join(lines, nil), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			sink, err := audit.NewFileSink(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sink.Close()
+
+			_, err = audit.Verify(ctx, sink, nil)
+			var verr *audit.VerifyError
+			assert.True(t, errors.As(err, &verr), err)
+			assert.Equal(t, tc.seq, verr.Seq)
+
+			_, err = audit.VerifyReader(ctx, bytes.NewReader(bytes.Join(lines, nil)), nil)
+			assert.True(t, errors.As(err, &verr), err)
+			assert.Equal(t, tc.seq, verr.Seq)
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	privateKey, err := crypt.GenerateES512PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Unix()
+	token, err := crypt.CreateAccessToken(privateKey, jwt.MapClaims{
+		"iss": "example.com",
+		"aud": "my-app",
+		"sub": "user-7",
+		"jti": "token-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": now,
+		"nbf": now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actor audit.Actor
+	h := audit.Middleware(&privateKey.PublicKey, "example.com", "my-app")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = audit.ActorFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/invoices", nil)
+	req.RemoteAddr = "192.0.2.1:5555"
+	req.Header.Set("Authorization", "Bearer "+token)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, audit.Actor{Subject: "user-7", Issuer: "example.com", TokenID: "token-1", IP: "192.0.2.1"}, actor)
+
+	req.Header.Set("Authorization", "Bearer invalid")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, audit.Actor{IP: "192.0.2.1"}, actor)
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLSink stores the entries in a table, e.g. of the database returned by
+// database/sql.New. The whole entry is kept as JSON in the entry column, the
+// other columns are for querying and are checked against it when read.
+type SQLSink struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLSink returns a sink for table, see CreateTable
+func NewSQLSink(db *sql.DB, table string) (*SQLSink, error) {
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	return &SQLSink{db: db, table: table}, nil
+}
+
+// CreateTable creates the table if it doesn't exist, with MySQL types
+func (s *SQLSink) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+s.table+` (
+	seq BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+	time DATETIME(6) NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	action VARCHAR(255) NOT NULL,
+	resource VARCHAR(1024) NOT NULL,
+	outcome VARCHAR(32) NOT NULL,
+	prev_hash CHAR(64) NOT NULL,
+	hash CHAR(64) NOT NULL,
+	entry TEXT NOT NULL,
+	INDEX (actor),
+	INDEX (time)
+)`)
+
+	return err
+}
+
+func (s *SQLSink) Append(ctx context.Context, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO `+s.table+` (seq, time, actor, action, resource, outcome, prev_hash, hash, entry) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Seq, e.Time, e.Actor.Subject, e.Action, e.Resource, string(e.Outcome), e.PrevHash, e.Hash, string(data),
+	)
+
+	return err
+}
+
+func (s *SQLSink) Last(ctx context.Context) (*Entry, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sqlColumns+` FROM `+s.table+` ORDER BY seq DESC LIMIT 1`)
+
+	e, err := scanEntry(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func (s *SQLSink) Scan(ctx context.Context, fn func(e Entry) error) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqlColumns+` FROM `+s.table+` ORDER BY seq`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// sqlColumns are read back to check that the columns used for querying
+// match the entry, which alone is covered by the hash. The time column is
+// not compared, its precision and time zone depend on the database.
+const sqlColumns = "seq, actor, action, resource, outcome, prev_hash, hash, entry"
+
+// scanEntry decodes the entry of a row, returning a *VerifyError when a
+// column doesn't match it
+func scanEntry(row interface{ Scan(dest ...any) error }) (Entry, error) {
+	var seq uint64
+	var actor, action, resource, outcome, prevHash, hash, data string
+
+	if err := row.Scan(&seq, &actor, &action, &resource, &outcome, &prevHash, &hash, &data); err != nil {
+		return Entry{}, err
+	}
+
+	var e Entry
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return Entry{}, err
+	}
+
+	for _, c := range []struct {
+		name         string
+		column, want string
+	}{
+		{"seq", strconv.FormatUint(seq, 10), strconv.FormatUint(e.Seq, 10)},
+		{"actor", actor, e.Actor.Subject},
+		{"action", action, e.Action},
+		{"resource", resource, e.Resource},
+		{"outcome", outcome, string(e.Outcome)},
+		{"prev_hash", prevHash, e.PrevHash},
+		{"hash", hash, e.Hash},
+	} {
+		if c.column != c.want {
+			return Entry{}, &VerifyError{Seq: seq, Reason: fmt.Sprintf("column %s doesn't match the entry", c.name)}
+		}
+	}
+
+	return e, nil
+}
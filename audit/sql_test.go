@@ -0,0 +1,168 @@
+package audit_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/audit"
+)
+
+// fakeDB is a database/sql driver understanding the statements of SQLSink,
+// keeping the inserted rows without their time column
+type fakeDB struct {
+	mu   sync.Mutex
+	rows [][]driver.Value
+}
+
+func (d *fakeDB) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.HasPrefix(s.query, "INSERT") {
+		s.db.mu.Lock()
+		defer s.db.mu.Unlock()
+		s.db.rows = append(s.db.rows, append([]driver.Value{args[0]}, args[2:]...))
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	rows := append([][]driver.Value(nil), s.db.rows...)
+	if strings.Contains(s.query, "DESC LIMIT 1") && len(rows) > 0 {
+		rows = rows[len(rows)-1:]
+	}
+
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct{ rows [][]driver.Value }
+
+func (r *fakeRows) Columns() []string {
+	return []string{"seq", "actor", "action", "resource", "outcome", "prev_hash", "hash", "entry"}
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
+
+func TestSQLSink(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeDB{}
+	sql.Register("fake-audit", fake)
+
+	db, err := sql.Open("fake-audit", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = audit.NewSQLSink(db, "audit; DROP TABLE users")
+	assert.Error(t, err)
+
+	sink, err := audit.NewSQLSink(db, "audit_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, sink.CreateTable(ctx))
+
+	last, err := sink.Last(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, last)
+
+	log, err := audit.New(ctx, sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, action := range []string{"a", "b"} {
+		if _, err := log.Log(ctx, audit.Entry{Action: action, Resource: "r"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	last, err = sink.Last(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", last.Action)
+
+	res, err := audit.Verify(ctx, sink, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.Entries)
+
+	for name, tc := range map[string]struct {
+		tamper func(rows [][]driver.Value)
+		seq    uint64
+	}{
+		"entry": {
+			tamper: func(rows [][]driver.Value) {
+				rows[0][7] = strings.Replace(rows[0][7].(string), `"resource":"r"`, `"resource":"s"`, 1)
+			},
+			seq: 1,
+		},
+		"actor column": {
+			tamper: func(rows [][]driver.Value) { rows[1][1] = "admin" },
+			seq:    2,
+		},
+		"hash column": {
+			tamper: func(rows [][]driver.Value) { rows[0][6] = strings.Repeat("0", 64) },
+			seq:    1,
+		},
+		"seq column": {
+			tamper: func(rows [][]driver.Value) { rows[0][0], rows[1][0] = rows[1][0], rows[0][0] },
+			seq:    2,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fake.mu.Lock()
+			saved := make([][]driver.Value, len(fake.rows))
+			for i, row := range fake.rows {
+				saved[i] = append([]driver.Value(nil), row...)
+			}
+			tc.tamper(fake.rows)
+			fake.mu.Unlock()
+
+			defer func() {
+				fake.mu.Lock()
+				fake.rows = saved
+				fake.mu.Unlock()
+			}()
+
+			_, err := audit.Verify(ctx, sink, nil)
+			var verr *audit.VerifyError
+			assert.True(t, errors.As(err, &verr), err)
+			assert.Equal(t, tc.seq, verr.Seq)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Command auditverify checks the hash chain of an audit trail written by the
+// audit package, to a file or to a MySQL table.
+//
+//	auditverify -file /var/log/app/audit.log
+//	AUDIT_HMAC_KEY=<hex> auditverify -dsn 'user:pass@tcp(db:3306)/app' -table audit_log
+//
+// It exits with status 1 when the trail was tampered with.
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/devshansharma/tools/audit"
+	dbsql "github.com/devshansharma/tools/database/sql"
+)
+
+func main() {
+	file := flag.String("file", "", "audit file to verify")
+	dsn := flag.String("dsn", "", "MySQL DSN of the database holding the audit table")
+	table := flag.String("table", "audit_log", "audit table, with -dsn")
+	keyEnv := flag.String("key-env", "AUDIT_HMAC_KEY", "environment variable holding the hex encoded HMAC key, if any")
+	head := flag.String("head", "", "expected hash of the last entry, to detect removed entries")
+	flag.Parse()
+
+	if err := run(*file, *dsn, *table, os.Getenv(*keyEnv), *head); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		var verr *audit.VerifyError
+		if errors.As(err, &verr) {
+			os.Exit(1)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(file, dsn, table, hexKey, head string) error {
+	ctx := context.Background()
+
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return fmt.Errorf("invalid HMAC key: %w", err)
+	}
+
+	var res audit.VerifyResult
+	switch {
+	case file != "" && dsn == "":
+		res, err = verifyFile(ctx, file, key)
+	case dsn != "" && file == "":
+		var sink audit.Sink
+		sink, err = audit.NewSQLSink(dbsql.New(dsn), table)
+		if err != nil {
+			return err
+		}
+		res, err = audit.Verify(ctx, sink, key)
+	default:
+		return errors.New("one of -file or -dsn is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	if head != "" && res.Head != head {
+		return &audit.VerifyError{Seq: uint64(res.Entries), Reason: "last entry is not the expected head, entries were removed"}
+	}
+
+	fmt.Printf("ok: %d entries, head %s\n", res.Entries, res.Head)
+
+	return nil
+}
+
+// verifyFile opens the file read-only, the trail being verified is never
+// created or written to
+func verifyFile(ctx context.Context, path string, key []byte) (audit.VerifyResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return audit.VerifyResult{}, err
+	}
+	defer f.Close()
+
+	return audit.VerifyReader(ctx, f, key)
+}
@@ -0,0 +1,87 @@
+// Command logdecrypt decrypts the fields encrypted by logger.NewEncryptor in
+// JSON or text logs, read from the given files or stdin.
+//
+//	LOG_ENCRYPTION_KEY=<hex key> logdecrypt app.log
+//	kubectl logs app | LOG_ENCRYPTION_KEY=<hex key> logdecrypt
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+func main() {
+	keyEnv := flag.String("key-env", "LOG_ENCRYPTION_KEY", "environment variable holding the hex encoded AES key")
+	flag.Parse()
+
+	key, err := hex.DecodeString(os.Getenv(*keyEnv))
+	if err != nil || len(key) == 0 {
+		fmt.Fprintf(os.Stderr, "%s must hold the hex encoded AES key\n", *keyEnv)
+		os.Exit(2)
+	}
+
+	if err := run(key, flag.Args(), os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(key []byte, files []string, out io.Writer) error {
+	if len(files) == 0 {
+		return decrypt(key, os.Stdin, out)
+	}
+
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+
+		err = decrypt(key, f, out)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decrypt copies the lines of r to w, decrypted. Lines with values that can't
+// be decrypted are copied as they are and reported once at the end.
+func decrypt(key []byte, r io.Reader, w io.Writer) error {
+	var failed int
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	for scanner.Scan() {
+		line, err := logger.DecryptLine(key, scanner.Text())
+		if err != nil {
+			failed++
+		}
+
+		if _, err := fmt.Fprintln(bw, line); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d lines had values that could not be decrypted, wrong key?", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"log/slog"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Watch for reloading the configuration file given to New whenever it changes.
+// Every change is unmarshalled into a fresh T and passed to onChange, so
+// values already handed out are never mutated. A file that fails to
+// unmarshal is logged and skipped, keeping the previous configuration.
+func Watch[T any](onChange func(cfg *T)) {
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		cfg := new(T)
+		if err := viper.Unmarshal(cfg); err != nil {
+			slog.Error("unable to unmarshal reloaded config",
+				slog.String("file", e.Name),
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+
+		onChange(cfg)
+	})
+
+	viper.WatchConfig()
+}
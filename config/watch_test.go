@@ -0,0 +1,63 @@
+package config_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/config"
+	"github.com/devshansharma/tools/middleware"
+)
+
+type appConfig struct {
+	Maintenance middleware.MaintenanceConfig `mapstructure:"maintenance"`
+}
+
+func TestWatch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"maintenance": {"enabled": false}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	maintenance := middleware.NewMaintenance()
+	cfg := config.New(&appConfig{}, file).(*appConfig)
+	assert.NoError(t, maintenance.Set(cfg.Maintenance))
+
+	config.Watch(func(cfg *appConfig) {
+		assert.NoError(t, maintenance.Set(cfg.Maintenance))
+	})
+
+	handler := middleware.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), maintenance.Middleware())
+	status := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, status())
+
+	err := os.WriteFile(file, []byte(`{"maintenance": {"prefixes": ["/orders"], "retry_after": 30}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Eventually(t, func() bool {
+		return status() == http.StatusServiceUnavailable
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"/orders"}, maintenance.Config().Prefixes)
+
+	// a file that fails to unmarshal keeps the previous configuration
+	err = os.WriteFile(file, []byte(`{"maintenance": {"retry_after": "soon"}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, status())
+	assert.Equal(t, 30, maintenance.Config().RetryAfter)
+}
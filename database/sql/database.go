@@ -2,10 +2,12 @@ package sql
 
 import (
 	"database/sql"
-	"log"
+	"log/slog"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/devshansharma/tools/logger"
 )
 
 // WithConnMaxLifeTime to call func SetConnMaxLifetime on db, default is 10 seconds
@@ -32,11 +34,11 @@ func WithMaxOpenConns(i int) func(*sql.DB) {
 func New(dsn string, configFuncs ...func(*sql.DB)) *sql.DB {
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		log.Fatalln("error while trying to create connection pool", dsn, err)
+		logger.Fatal(slog.Default(), "error while trying to create connection pool", "error", err)
 	}
 
 	if err := db.Ping(); err != nil {
-		log.Fatalln("error while trying to ping", dsn, err.Error())
+		logger.Fatal(slog.Default(), "error while trying to ping", "error", err)
 	}
 
 	db.SetConnMaxLifetime(10 * time.Second)
@@ -0,0 +1,310 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy decides what happens to a record when the queue is full
+type OverflowPolicy int
+
+const (
+	// Block waits for room in the queue
+	Block OverflowPolicy = iota
+	// DropNewest drops the record being logged
+	DropNewest
+	// DropOldest drops the oldest queued record to make room
+	DropOldest
+	// DropBelowLevel drops records below the drop level and blocks for the others
+	DropBelowLevel
+)
+
+// AsyncOption to configure AsyncHandler
+type AsyncOption func(a *asyncQueue)
+
+// WithQueueSize for the number of queued records, default is 1024
+func WithQueueSize(n int) AsyncOption {
+	return func(a *asyncQueue) {
+		a.size = n
+	}
+}
+
+// WithOverflowPolicy for what to do when the queue is full, default is Block
+func WithOverflowPolicy(p OverflowPolicy) AsyncOption {
+	return func(a *asyncQueue) {
+		a.policy = p
+	}
+}
+
+// WithDropLevel for the level under which DropBelowLevel drops records, default is WARN
+func WithDropLevel(l slog.Level) AsyncOption {
+	return func(a *asyncQueue) {
+		a.dropLevel = l
+	}
+}
+
+// WithAsync for writing records from a background goroutine, see AsyncHandler.
+// Call Close with the logger on shutdown to flush the queue.
+func WithAsync(opts ...AsyncOption) func(*CustomLogger) {
+	return func(cl *CustomLogger) {
+		cl.async = true
+		cl.asyncOpts = opts
+	}
+}
+
+type asyncEntry struct {
+	ctx     context.Context
+	handler slog.Handler
+	rec     slog.Record
+}
+
+// asyncQueue is shared by an AsyncHandler and the handlers derived from it
+type asyncQueue struct {
+	size      int
+	policy    OverflowPolicy
+	dropLevel slog.Level
+
+	mu     sync.RWMutex
+	closed bool
+	ch     chan asyncEntry
+	done   chan struct{}
+
+	// root receives the reports of dropped records
+	root     atomic.Pointer[slog.Handler]
+	queued   atomic.Uint64
+	handled  atomic.Uint64
+	dropped  atomic.Uint64
+	reported uint64
+}
+
+// AsyncHandler queues records and hands them to the wrapped handler from a
+// background goroutine, so slow writers don't add latency to the caller.
+// Once closed, records are handled synchronously.
+type AsyncHandler struct {
+	handler slog.Handler
+	queue   *asyncQueue
+}
+
+// NewAsyncHandler starts the goroutine writing records to h
+func NewAsyncHandler(h slog.Handler, opts ...AsyncOption) *AsyncHandler {
+	q := &asyncQueue{
+		size:      1024,
+		dropLevel: slog.LevelWarn,
+		done:      make(chan struct{}),
+	}
+	q.root.Store(&h)
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	q.ch = make(chan asyncEntry, q.size)
+	go q.run()
+
+	return &AsyncHandler{handler: h, queue: q}
+}
+
+func (a *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return a.handler.Enabled(ctx, level)
+}
+
+func (a *AsyncHandler) Handle(ctx context.Context, rec slog.Record) error {
+	q := a.queue
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return a.handler.Handle(ctx, rec)
+	}
+
+	entry := asyncEntry{
+		ctx:     context.WithoutCancel(ctx),
+		handler: a.handler,
+		rec:     rec.Clone(),
+	}
+
+	select {
+	case q.ch <- entry:
+		q.queued.Add(1)
+		return nil
+	default:
+	}
+
+	switch {
+	case q.policy == DropNewest, q.policy == DropBelowLevel && rec.Level < q.dropLevel:
+		q.dropped.Add(1)
+		return nil
+
+	case q.policy == DropOldest:
+		for {
+			select {
+			case q.ch <- entry:
+				q.queued.Add(1)
+				return nil
+			default:
+			}
+
+			select {
+			case <-q.ch:
+				q.handled.Add(1)
+				q.dropped.Add(1)
+			default:
+			}
+		}
+	}
+
+	q.ch <- entry
+	q.queued.Add(1)
+
+	return nil
+}
+
+func (a *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{handler: a.handler.WithAttrs(attrs), queue: a.queue}
+}
+
+func (a *AsyncHandler) WithGroup(name string) slog.Handler {
+	return &AsyncHandler{handler: a.handler.WithGroup(name), queue: a.queue}
+}
+
+// withHandler returns an AsyncHandler queueing to the same queue for h,
+// records already queued are handled by the handler they were queued for
+func (a *AsyncHandler) withHandler(h slog.Handler) *AsyncHandler {
+	a.queue.root.Store(&h)
+
+	return &AsyncHandler{handler: h, queue: a.queue}
+}
+
+// Dropped returns the number of records dropped because the queue was full
+func (a *AsyncHandler) Dropped() uint64 {
+	return a.queue.dropped.Load()
+}
+
+// Flush waits until the records queued before the call are handled
+func (a *AsyncHandler) Flush(ctx context.Context) error {
+	q := a.queue
+	target := q.queued.Load()
+
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	for q.handled.Load() < target {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-q.done:
+			return nil
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// Close stops queueing, and waits until the queued records are handled
+func (a *AsyncHandler) Close(ctx context.Context) error {
+	q := a.queue
+
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	for entry := range q.ch {
+		// errors can't reach the caller anymore, as slog.Logger ignores them anyway
+		_ = entry.handler.Handle(entry.ctx, entry.rec)
+		q.handled.Add(1)
+
+		if len(q.ch) == 0 {
+			q.reportDropped()
+		}
+	}
+}
+
+// reportDropped logs how many records were dropped since the last report
+func (q *asyncQueue) reportDropped() {
+	dropped := q.dropped.Load()
+	if dropped == q.reported {
+		return
+	}
+
+	rec := slog.NewRecord(time.Now(), slog.LevelWarn, "log records dropped, queue full", 0)
+	rec.AddAttrs(slog.Uint64("dropped", dropped-q.reported))
+	q.reported = dropped
+
+	_ = (*q.root.Load()).Handle(context.Background(), rec)
+}
+
+// flusher and closer are implemented by handlers holding buffered records
+type (
+	flusher interface {
+		Flush(ctx context.Context) error
+	}
+	closer interface {
+		Close(ctx context.Context) error
+	}
+)
+
+// Flush waits until the records buffered by the logger are written
+func Flush(ctx context.Context, l *slog.Logger) error {
+	return flushOrClose(ctx, rootHandler(l), false)
+}
+
+// Close flushes the records buffered by the logger and stops its background
+// goroutines, records logged afterwards are written synchronously. It fits
+// server.WithShutdownFunc.
+func Close(ctx context.Context, l *slog.Logger) error {
+	return flushOrClose(ctx, rootHandler(l), true)
+}
+
+func rootHandler(l *slog.Logger) slog.Handler {
+	if h, ok := l.Handler().(*customHandler); ok {
+		return h.core.state.Load().handler
+	}
+
+	return l.Handler()
+}
+
+func flushOrClose(ctx context.Context, h slog.Handler, close bool) error {
+	var errs []error
+
+	// the handler itself first, so that queued records reach the ones below it
+	if c, ok := h.(closer); ok && close {
+		errs = append(errs, c.Close(ctx))
+	} else if f, ok := h.(flusher); ok {
+		errs = append(errs, f.Flush(ctx))
+	}
+
+	switch v := h.(type) {
+	case *MultiHandler:
+		for _, child := range v.handlers {
+			errs = append(errs, flushOrClose(ctx, child, close))
+		}
+	case *levelHandler:
+		errs = append(errs, flushOrClose(ctx, v.Handler, close))
+	case *AsyncHandler:
+		errs = append(errs, flushOrClose(ctx, v.handler, close))
+	case *SamplingHandler:
+		errs = append(errs, flushOrClose(ctx, v.handler, close))
+	}
+
+	return errors.Join(errs...)
+}
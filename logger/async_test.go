@@ -0,0 +1,141 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+// gatedWriter blocks writes until the gate is opened
+type gatedWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	<-g.gate
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.Write(p)
+}
+
+func (g *gatedWriter) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.String()
+}
+
+func TestAsyncHandler(t *testing.T) {
+	t.Run("flush and close", func(t *testing.T) {
+		w := &gatedWriter{gate: make(chan struct{})}
+		close(w.gate)
+
+		log := logger.NewLogger(logger.WithWriter(w), logger.WithLevel("info"), logger.WithAsync())
+		for i := 0; i < 100; i++ {
+			log.With("i", i).Info("hello")
+		}
+
+		assert.NoError(t, logger.Flush(context.Background(), log))
+		assert.Equal(t, 100, strings.Count(w.String(), "msg=hello"))
+
+		assert.NoError(t, logger.Close(context.Background(), log))
+		log.Info("after close")
+		assert.Contains(t, w.String(), "after close")
+	})
+
+	t.Run("reconfigure keeps the queue", func(t *testing.T) {
+		w := &gatedWriter{gate: make(chan struct{})}
+
+		log := logger.NewLogger(logger.WithWriter(w), logger.WithLevel("info"), logger.WithAsync())
+		log.Info("queued")
+
+		goroutines := runtime.NumGoroutine()
+		for _, level := range []string{"debug", "warn", "info", "debug", "info"} {
+			assert.NoError(t, logger.Reconfigure(log, logger.WithLevel(level)))
+			log.Info("reconfigured")
+		}
+		assert.Equal(t, goroutines, runtime.NumGoroutine())
+
+		close(w.gate)
+		assert.NoError(t, logger.Close(context.Background(), log))
+		assert.Equal(t, 1, strings.Count(w.String(), "msg=queued"))
+		assert.Equal(t, 4, strings.Count(w.String(), "msg=reconfigured"))
+	})
+
+	t.Run("drop newest", func(t *testing.T) {
+		w := &gatedWriter{gate: make(chan struct{})}
+		h := logger.NewAsyncHandler(slog.NewTextHandler(w, nil),
+			logger.WithQueueSize(2),
+			logger.WithOverflowPolicy(logger.DropNewest),
+		)
+		log := slog.New(h)
+
+		for i := 0; i < 10; i++ {
+			log.Info("hello", "i", i)
+		}
+
+		// the writer holds one record and the queue two
+		assert.GreaterOrEqual(t, h.Dropped(), uint64(7))
+		close(w.gate)
+
+		assert.NoError(t, h.Close(context.Background()))
+		assert.Contains(t, w.String(), "i=0")
+		assert.NotContains(t, w.String(), "i=9")
+		assert.Contains(t, w.String(), "log records dropped")
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		w := &gatedWriter{gate: make(chan struct{})}
+		h := logger.NewAsyncHandler(slog.NewTextHandler(w, nil),
+			logger.WithQueueSize(2),
+			logger.WithOverflowPolicy(logger.DropOldest),
+		)
+		log := slog.New(h)
+
+		for i := 0; i < 10; i++ {
+			log.Info("hello", "i", i)
+		}
+		close(w.gate)
+
+		assert.NoError(t, h.Close(context.Background()))
+		assert.Contains(t, w.String(), "i=9")
+		assert.Contains(t, w.String(), "i=8")
+		assert.GreaterOrEqual(t, h.Dropped(), uint64(7))
+	})
+
+	t.Run("drop below level", func(t *testing.T) {
+		w := &gatedWriter{gate: make(chan struct{})}
+		h := logger.NewAsyncHandler(slog.NewTextHandler(w, nil),
+			logger.WithQueueSize(1),
+			logger.WithOverflowPolicy(logger.DropBelowLevel),
+			logger.WithDropLevel(slog.LevelError),
+		)
+		log := slog.New(h)
+
+		for i := 0; i < 5; i++ {
+			log.Info("hello", "i", i)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			log.Error("kept")
+			close(done)
+		}()
+		close(w.gate)
+		<-done
+
+		assert.NoError(t, h.Close(context.Background()))
+		assert.Contains(t, w.String(), "msg=kept")
+		assert.Greater(t, h.Dropped(), uint64(0))
+	})
+}
@@ -0,0 +1,114 @@
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+// benchLog logs the attrs of a typical request log
+func benchLog(log *slog.Logger) {
+	log.Info("http request",
+		"method", "GET",
+		"path", "/api/v1/orders",
+		"status", 200,
+		"duration", 1500*time.Microsecond,
+		"bytes", uint64(5120),
+		"cached", true,
+	)
+}
+
+var errBench = fmt.Errorf("charge: %w", errors.New("card declined"))
+
+func benchError(log *slog.Logger) {
+	log.Error("charge failed", "error", errBench, "tenant", "acme")
+}
+
+// newBenchLogger returns a JSON logger at INFO writing to io.Discard
+func newBenchLogger(opts ...func(*logger.CustomLogger)) *slog.Logger {
+	return logger.NewLogger(append([]func(*logger.CustomLogger){
+		logger.WithWriter(io.Discard),
+		logger.WithJSON(true),
+		logger.WithLevel("info"),
+	}, opts...)...)
+}
+
+func BenchmarkLogger(b *testing.B) {
+	source := []func(*logger.CustomLogger){logger.WithSource(true), logger.WithReplaceAttr(logger.WithShortFileName)}
+	errorTrace := []func(*logger.CustomLogger){logger.WithReplaceAttr(logger.WithErrorTrace)}
+
+	cases := []struct {
+		name string
+		log  *slog.Logger
+		fn   func(log *slog.Logger)
+	}{
+		{"slog JSONHandler", slog.New(slog.NewJSONHandler(io.Discard, nil)), benchLog},
+		{"text", logger.NewLogger(logger.WithWriter(io.Discard), logger.WithLevel("info")), benchLog},
+		{"JSON", newBenchLogger(), benchLog},
+		{"JSON with source", newBenchLogger(source...), benchLog},
+		{"JSON with error trace", newBenchLogger(errorTrace...), benchError},
+		{"JSON with attrs", newBenchLogger().With("service", "orders", "version", "1.2.3").WithGroup("req"), benchLog},
+		{"fast JSON", newBenchLogger(logger.WithFastJSON(true)), benchLog},
+		{"fast JSON with source", newBenchLogger(append(source, logger.WithFastJSON(true))...), benchLog},
+		{"fast JSON with error trace", newBenchLogger(append(errorTrace, logger.WithFastJSON(true))...), benchError},
+		{"fast JSON with attrs", newBenchLogger(logger.WithFastJSON(true)).With("service", "orders", "version", "1.2.3").WithGroup("req"), benchLog},
+		{"FastHandler with short source", slog.New(logger.NewFastHandler(io.Discard, &slog.HandlerOptions{AddSource: true}, logger.WithShortSource(true))), benchLog},
+		{"disabled", newBenchLogger(logger.WithLevel("warn")), benchLog},
+	}
+
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				c.fn(c.log)
+			}
+		})
+	}
+}
+
+func BenchmarkReplaceAttr(b *testing.B) {
+	pathErr := &fs.PathError{Op: "open", Path: "/etc/app.yaml", Err: fs.ErrNotExist}
+
+	cases := []struct {
+		name string
+		f    logger.ReplaceAttrFunc
+		attr func() slog.Attr
+	}{
+		{"WithShortFileName", logger.WithShortFileName, func() slog.Attr {
+			return slog.Any(slog.SourceKey, &slog.Source{File: "/src/app/internal/orders/handler.go", Line: 42})
+		}},
+		{"WithErrorTrace", logger.WithErrorTrace, func() slog.Attr { return slog.Any("error", errBench) }},
+		{"WithErrorTrace struct error", logger.WithErrorTrace, func() slog.Attr { return slog.Any("error", pathErr) }},
+		{"string attr", logger.WithShortFileNameAndErrorTrace, func() slog.Attr { return slog.String("path", "/api/v1/orders") }},
+	}
+
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				c.f(nil, c.attr())
+			}
+		})
+	}
+}
+
+func BenchmarkFastHandlerParallel(b *testing.B) {
+	log := slog.New(logger.NewFastHandler(io.Discard, &slog.HandlerOptions{AddSource: true}, logger.WithShortSource(true)))
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			log.InfoContext(ctx, "http request", "method", "GET", "status", 200)
+		}
+	})
+}
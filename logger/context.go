@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+type contextAttrsKey struct{}
+
+// WithContextAttrs returns a copy of ctx carrying attrs, on top of the ones
+// already in ctx. An attr replaces an earlier one with the same key.
+// ContextAttrsHandle adds them to every record logged with the context.
+func WithContextAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing := ContextAttrs(ctx)
+
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	for _, a := range existing {
+		if !hasKey(attrs, a.Key) {
+			merged = append(merged, a)
+		}
+	}
+
+	for i, a := range attrs {
+		if !hasKey(attrs[i+1:], a.Key) {
+			merged = append(merged, a)
+		}
+	}
+
+	return context.WithValue(ctx, contextAttrsKey{}, merged)
+}
+
+// ContextAttrs returns the attrs stored in ctx by WithContextAttrs
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+
+	attrs, _ := ctx.Value(contextAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// ContextAttrsHandle is a HandleFunc adding the attrs stored with
+// WithContextAttrs to the record, use it with WithHandle. Attrs already in
+// the record are kept, e.g. the request_id of the access log middleware.
+func ContextAttrsHandle(ctx context.Context, rec slog.Record) (slog.Record, error) {
+	attrs := ContextAttrs(ctx)
+	if len(attrs) == 0 {
+		return rec, nil
+	}
+
+	rec.Attrs(func(a slog.Attr) bool {
+		if hasKey(attrs, a.Key) {
+			attrs = slices.DeleteFunc(slices.Clone(attrs), func(c slog.Attr) bool { return c.Key == a.Key })
+		}
+		return len(attrs) > 0
+	})
+
+	rec.AddAttrs(attrs...)
+
+	return rec, nil
+}
+
+// ChainHandle runs the HandleFuncs in order, stopping at the first error
+func ChainHandle(fs ...HandleFunc) HandleFunc {
+	return func(ctx context.Context, rec slog.Record) (slog.Record, error) {
+		var err error
+
+		for _, f := range fs {
+			rec, err = f(ctx, rec)
+			if err != nil {
+				return rec, err
+			}
+		}
+
+		return rec, nil
+	}
+}
+
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
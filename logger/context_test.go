@@ -0,0 +1,41 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+func TestContextAttrs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(
+		logger.WithWriter(buf),
+		logger.WithJSON(true),
+		logger.WithHandle(logger.ChainHandle(
+			logger.ContextAttrsHandle,
+			func(ctx context.Context, rec slog.Record) (slog.Record, error) {
+				rec.AddAttrs(slog.Bool("chained", true))
+				return rec, nil
+			},
+		)),
+	)
+
+	ctx := logger.WithContextAttrs(context.Background(),
+		slog.String("request_id", "req-1"),
+		slog.String("tenant", "acme"),
+	)
+	ctx = logger.WithContextAttrs(ctx, slog.String("user_id", "u-1"), slog.String("tenant", "globex"))
+
+	log.WarnContext(ctx, "hello")
+
+	assert.Equal(t,
+		`"msg":"hello","request_id":"req-1","user_id":"u-1","tenant":"globex","chained":true}`+"\n",
+		buf.String()[bytes.Index(buf.Bytes(), []byte(`"msg"`)):],
+	)
+	assert.Len(t, logger.ContextAttrs(context.Background()), 0)
+}
@@ -2,10 +2,13 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // make sure it's idempotent
@@ -20,15 +23,25 @@ type (
 )
 
 type CustomLogger struct {
-	writer      io.Writer
-	replaceAttr ReplaceAttrFunc
-	handle      HandleFunc
-	addSource   bool
-	level       string
-	isJSON      bool
+	writer       io.Writer
+	replaceAttr  ReplaceAttrFunc
+	handle       HandleFunc
+	addSource    bool
+	level        string
+	levelSpec    string
+	levels       *Levels
+	isJSON       bool
+	fastJSON     bool
+	pretty       bool
+	sinks        []Sink
+	sampling     bool
+	samplingOpts []SamplingOption
+	async        bool
+	asyncOpts    []AsyncOption
+	captureStack *captureStack
 }
 
-var logger *slog.Logger
+var logger atomic.Pointer[slog.Logger]
 
 func WithWriter(wr io.Writer) func(*CustomLogger) {
 	return func(cl *CustomLogger) {
@@ -54,75 +67,417 @@ func WithHandle(h HandleFunc) func(*CustomLogger) {
 	}
 }
 
+// WithLevel for the default level, see ParseLevel for the accepted names
 func WithLevel(l string) func(*CustomLogger) {
 	return func(cl *CustomLogger) {
 		cl.level = l
 	}
 }
 
+// WithLevelSpec for per component levels, e.g. "warn,database=debug", see Levels.SetSpec
+func WithLevelSpec(spec string) func(*CustomLogger) {
+	return func(cl *CustomLogger) {
+		cl.levelSpec = spec
+	}
+}
+
+// WithLevels for sharing levels between loggers or changing them at runtime,
+// WithLevel and WithLevelSpec are applied on top of them
+func WithLevels(lv *Levels) func(*CustomLogger) {
+	return func(cl *CustomLogger) {
+		cl.levels = lv
+	}
+}
+
 func WithJSON(b bool) func(*CustomLogger) {
 	return func(cl *CustomLogger) {
 		cl.isJSON = b
 	}
 }
 
+// New creates the global logger on the first call and returns it on every
+// call, ignoring the options after the first one. Prefer NewLogger and SetGlobal.
 func New(opts ...func(l *CustomLogger)) *slog.Logger {
 	once.Do(func() {
-		handler := new(opts...)
-		logger = slog.New(handler)
+		if logger.Load() == nil {
+			SetGlobal(NewLogger(opts...))
+		}
 	})
 
-	return logger
+	return GetLogger()
 }
 
-// TODO: can write a test function for the same...
-func new(opts ...func(l *CustomLogger)) customHandler {
-	l := &CustomLogger{
-		writer: os.Stdout,
-		level:  "warn",
+// NewLogger always returns a new logger, independent of the global one.
+// When the level or level spec is invalid, it falls back to the default
+// level and logs the error as a warning, see TryNewLogger.
+func NewLogger(opts ...func(l *CustomLogger)) *slog.Logger {
+	log, err := TryNewLogger(opts...)
+	if err == nil {
+		return log
+	}
+
+	h, _ := newHandler(append(opts[:len(opts):len(opts)], WithLevel(""), WithLevelSpec(""))...)
+	log = slog.New(h)
+	log.Warn("invalid log level, using the default", "error", err)
+
+	return log
+}
+
+// TryNewLogger is NewLogger returning the error of an invalid level or level
+// spec, e.g. to fail the startup on a bad configuration
+func TryNewLogger(opts ...func(l *CustomLogger)) (*slog.Logger, error) {
+	h, err := newHandler(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return slog.New(h), nil
+}
+
+// Named returns a logger for a component, with its own level if one is set
+// in the level spec, and a "component" attribute
+func Named(l *slog.Logger, name string) *slog.Logger {
+	h, ok := l.Handler().(*customHandler)
+	if !ok {
+		return l.With(slog.String("component", name))
 	}
 
+	named := h.with(handlerOp{attrs: []slog.Attr{slog.String("component", name)}})
+	named.component = name
+
+	return slog.New(named)
+}
+
+// LevelsOf returns the levels used by a logger created by this package, to
+// change them at runtime
+func LevelsOf(l *slog.Logger) (*Levels, bool) {
+	h, ok := l.Handler().(*customHandler)
+	if !ok {
+		return nil, false
+	}
+
+	return h.core.state.Load().config.levels, true
+}
+
+// SetGlobal makes l the logger returned by GetLogger and the slog default
+func SetGlobal(l *slog.Logger) {
+	logger.Store(l)
+	slog.SetDefault(l)
+}
+
+// Reconfigure applies opts on top of the options l was created with, and
+// swaps writer, format, level and hooks atomically for l and every logger
+// derived from it. Records being handled complete with the previous setup.
+func Reconfigure(l *slog.Logger, opts ...func(l *CustomLogger)) error {
+	h, ok := l.Handler().(*customHandler)
+	if !ok {
+		return fmt.Errorf("logger was not created by the logger package")
+	}
+
+	h.core.mu.Lock()
+	defer h.core.mu.Unlock()
+
+	// levels are only touched when asked to, they may have been changed at
+	// runtime, so the asked ones are applied even when they equal the ones
+	// l was created with. The options run once, on cleared level fields, to
+	// tell which ones were asked.
+	prev := h.core.state.Load().config
+	cfg := prev
+	cfg.level, cfg.levelSpec = "", ""
 	for _, opt := range opts {
-		opt(l)
+		opt(&cfg)
 	}
 
-	options := slog.HandlerOptions{
-		AddSource: l.addSource,
-		Level:     getLevel(l.level),
+	asked := CustomLogger{level: cfg.level, levelSpec: cfg.levelSpec}
+	if cfg.level == "" {
+		cfg.level = prev.level
+	}
+	if cfg.levelSpec == "" {
+		cfg.levelSpec = prev.levelSpec
+	}
+
+	if cfg.levels != prev.levels {
+		if err := cfg.applyLevels(); err != nil {
+			return err
+		}
+	} else if asked.level != "" || asked.levelSpec != "" {
+		asked.levels = cfg.levels
+		if err := asked.applyLevels(); err != nil {
+			return err
+		}
+	}
+
+	prevState := h.core.state.Load()
+	st := cfg.build(prevState)
+	h.core.state.Store(st)
+
+	// a replaced queue is drained in the background, into the previous writer
+	if async, ok := prevState.handler.(*AsyncHandler); ok && !sharesQueue(st.handler, async) {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), asyncCloseTimeout)
+			defer cancel()
+
+			_ = async.Close(ctx)
+		}()
+	}
+
+	return nil
+}
+
+// asyncCloseTimeout bounds the draining of a queue replaced by Reconfigure
+const asyncCloseTimeout = 10 * time.Second
+
+func sharesQueue(h slog.Handler, async *AsyncHandler) bool {
+	a, ok := h.(*AsyncHandler)
+	return ok && a.queue == async.queue
+}
+
+func newHandler(opts ...func(l *CustomLogger)) (*customHandler, error) {
+	cfg := CustomLogger{
+		writer: os.Stdout,
 	}
 
-	if l.replaceAttr != nil {
-		options.ReplaceAttr = l.replaceAttr
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := cfg.applyLevels(); err != nil {
+		return nil, err
 	}
 
-	if l.isJSON {
-		return customHandler{
-			Handler:    slog.NewJSONHandler(l.writer, &options),
-			handleFunc: l.handle,
+	c := &core{}
+	c.state.Store(cfg.build(nil))
+
+	return &customHandler{core: c}, nil
+}
+
+// applyLevels validates level and levelSpec before setting them on levels
+func (l *CustomLogger) applyLevels() error {
+	var def *slog.Level
+	if l.level != "" {
+		lvl, err := ParseLevel(l.level)
+		if err != nil {
+			return err
 		}
+		def = &lvl
 	}
 
-	return customHandler{
-		Handler:    slog.NewTextHandler(l.writer, &options),
+	if _, _, err := parseSpec(l.levelSpec); err != nil {
+		return err
+	}
+
+	// warn unless told otherwise
+	if l.levels == nil {
+		l.levels = NewLevels(slog.LevelWarn)
+	}
+
+	if def != nil {
+		l.levels.Set("", *def)
+	}
+
+	if l.levelSpec != "" {
+		return l.levels.SetSpec(l.levelSpec)
+	}
+
+	return nil
+}
+
+// build creates the slog.Handler described by the options. The async queue
+// of prev is kept when the async options didn't change, e.g. when only the
+// level was reconfigured.
+func (l CustomLogger) build(prev *coreState) *coreState {
+	st := &coreState{
+		config:     l,
 		handleFunc: l.handle,
+		stack:      l.captureStack,
+	}
+
+	if len(l.sinks) == 0 {
+		format := FormatText
+		if l.isJSON {
+			format = FormatJSON
+		}
+		if l.pretty {
+			format = FormatPretty
+		}
+
+		st.handler = l.newFormatHandler(l.writer, format, nil, l.replaceAttr)
+	} else {
+		handlers := make([]slog.Handler, len(l.sinks))
+		for i, sink := range l.sinks {
+			handlers[i] = l.newSinkHandler(sink)
+		}
+
+		st.handler = NewMultiHandler(handlers...)
+		if len(handlers) == 1 {
+			st.handler = handlers[0]
+		}
+	}
+
+	if l.sampling {
+		st.handler = NewSamplingHandler(st.handler, l.samplingOpts...)
+	}
+
+	if l.async {
+		if async, ok := prev.asyncHandler(); ok && sameOptions(prev.config.asyncOpts, l.asyncOpts) {
+			st.handler = async.withHandler(st.handler)
+		} else {
+			st.handler = NewAsyncHandler(st.handler, l.asyncOpts...)
+		}
+	}
+
+	return st
+}
+
+// newFormatHandler creates the slog handler writing the format to w
+func (l CustomLogger) newFormatHandler(w io.Writer, format Format, level slog.Leveler, replaceAttr ReplaceAttrFunc) slog.Handler {
+	if level == nil {
+		level = levelAll
 	}
+
+	options := slog.HandlerOptions{
+		AddSource:   l.addSource,
+		Level:       level,
+		ReplaceAttr: withLevelName(replaceAttr),
+	}
+
+	switch {
+	case format == FormatPretty && isTerminal(w):
+		return NewPrettyHandler(w, &options)
+	case l.fastJSON && (format == FormatJSON || format == FormatPretty):
+		// level names are built in
+		options.ReplaceAttr = replaceAttr
+		return NewFastHandler(w, &options)
+	case format == FormatJSON, format == FormatPretty:
+		return slog.NewJSONHandler(w, &options)
+	}
+
+	return slog.NewTextHandler(w, &options)
+}
+
+// asyncHandler returns the async handler of the state, if any
+func (st *coreState) asyncHandler() (*AsyncHandler, bool) {
+	if st == nil {
+		return nil, false
+	}
+
+	async, ok := st.handler.(*AsyncHandler)
+
+	return async, ok
+}
+
+// sameOptions reports whether the options are the very same, as set by a
+// single call of an option such as WithAsync
+func sameOptions[T any](a, b []T) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// core is shared by a logger and all the loggers derived from it, so that
+// Reconfigure reaches all of them
+type core struct {
+	mu    sync.Mutex
+	state atomic.Pointer[coreState]
+}
+
+type coreState struct {
+	config     CustomLogger
+	handler    slog.Handler
+	handleFunc HandleFunc
+	stack      *captureStack
+}
+
+// handlerOp replays a WithAttrs or WithGroup call on a rebuilt handler
+type handlerOp struct {
+	attrs []slog.Attr
+	group string
+}
+
+// resolved caches the handler built for a given core state
+type resolved struct {
+	state   *coreState
+	handler slog.Handler
 }
 
 // customHandler for changing behaviour as per need
 type customHandler struct {
-	slog.Handler
-	handleFunc HandleFunc
+	core      *core
+	ops       []handlerOp
+	component string
+	cache     atomic.Pointer[resolved]
 }
 
-func (c customHandler) Handle(ctx context.Context, rec slog.Record) error {
+// resolve returns the current handler with the attrs and groups of this
+// handler applied, rebuilding it only after a Reconfigure
+func (c *customHandler) resolve() (*coreState, slog.Handler) {
+	st := c.core.state.Load()
+
+	if r := c.cache.Load(); r != nil && r.state == st {
+		return st, r.handler
+	}
+
+	h := st.handler
+	for _, op := range c.ops {
+		if op.group != "" {
+			h = h.WithGroup(op.group)
+		} else {
+			h = h.WithAttrs(op.attrs)
+		}
+	}
+
+	c.cache.Store(&resolved{state: st, handler: h})
+
+	return st, h
+}
+
+func (c *customHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	st, h := c.resolve()
+	return level >= st.config.levels.Level(c.component) && h.Enabled(ctx, level)
+}
+
+func (c *customHandler) Handle(ctx context.Context, rec slog.Record) error {
 	var err error
 
-	if c.handleFunc != nil {
-		rec, err = c.handleFunc(ctx, rec)
+	st, h := c.resolve()
+
+	if st.handleFunc != nil {
+		rec, err = st.handleFunc(ctx, rec)
 		if err != nil {
 			return err
 		}
 	}
 
-	return c.Handler.Handle(ctx, rec)
+	if st.stack != nil {
+		st.stack.add(&rec)
+	}
+
+	return h.Handle(ctx, rec)
+}
+
+// WithAttrs keeps handleFunc on loggers derived with slog.Logger.With
+func (c *customHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return c
+	}
+
+	return c.with(handlerOp{attrs: attrs})
+}
+
+// WithGroup keeps handleFunc on loggers derived with slog.Logger.WithGroup
+func (c *customHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return c
+	}
+
+	return c.with(handlerOp{group: name})
+}
+
+func (c *customHandler) with(op handlerOp) *customHandler {
+	ops := make([]handlerOp, len(c.ops), len(c.ops)+1)
+	copy(ops, c.ops)
+
+	return &customHandler{
+		core:      c.core,
+		ops:       append(ops, op),
+		component: c.component,
+	}
 }
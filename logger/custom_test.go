@@ -0,0 +1,137 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+type ctxKey string
+
+func TestCustomHandlerKeepsHandleFunc(t *testing.T) {
+	handle := func(ctx context.Context, rec slog.Record) (slog.Record, error) {
+		if id, ok := ctx.Value(ctxKey("requestID")).(string); ok {
+			rec.Add("requestID", id)
+		}
+
+		return rec, nil
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey("requestID"), "req-1")
+
+	decode := func(t *testing.T, buf *bytes.Buffer) map[string]any {
+		var entry map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+
+		return entry
+	}
+
+	t.Run("with", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(logger.WithWriter(buf), logger.WithJSON(true), logger.WithHandle(handle))
+
+		log.With("k", "v").WarnContext(ctx, "hello")
+
+		entry := decode(t, buf)
+		assert.Equal(t, "v", entry["k"])
+		assert.Equal(t, "req-1", entry["requestID"])
+	})
+
+	t.Run("with group", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(logger.WithWriter(buf), logger.WithJSON(true), logger.WithHandle(handle))
+
+		log.WithGroup("g").WarnContext(ctx, "hello", "k", "v")
+
+		entry := decode(t, buf)
+		assert.Equal(t, map[string]any{"k": "v", "requestID": "req-1"}, entry["g"])
+	})
+
+	t.Run("nested groups", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(logger.WithWriter(buf), logger.WithJSON(true), logger.WithHandle(handle))
+
+		log.With("a", 1).WithGroup("outer").With("b", 2).WithGroup("inner").WarnContext(ctx, "hello", "c", 3)
+
+		entry := decode(t, buf)
+		assert.Equal(t, float64(1), entry["a"])
+		outer := entry["outer"].(map[string]any)
+		assert.Equal(t, float64(2), outer["b"])
+		assert.Equal(t, map[string]any{"c": float64(3), "requestID": "req-1"}, outer["inner"])
+	})
+}
+
+func TestNewLogger(t *testing.T) {
+	t.Run("independent loggers", func(t *testing.T) {
+		jsonBuf, textBuf := &bytes.Buffer{}, &bytes.Buffer{}
+		jsonLog := logger.NewLogger(logger.WithWriter(jsonBuf), logger.WithJSON(true))
+		textLog := logger.NewLogger(logger.WithWriter(textBuf))
+
+		jsonLog.Warn("hello")
+		textLog.Warn("hello")
+
+		assert.Contains(t, jsonBuf.String(), `"msg":"hello"`)
+		assert.Contains(t, textBuf.String(), "msg=hello")
+	})
+
+	t.Run("set global", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(logger.WithWriter(buf))
+		logger.SetGlobal(log)
+
+		assert.Same(t, log, logger.GetLogger())
+		assert.Same(t, log, logger.New(logger.WithJSON(true)))
+	})
+
+	t.Run("reconfigure", func(t *testing.T) {
+		before, after := &bytes.Buffer{}, &bytes.Buffer{}
+		log := logger.NewLogger(logger.WithWriter(before), logger.WithLevel("warn"))
+		derived := log.With("k", "v")
+
+		log.Info("dropped")
+		assert.Empty(t, before.String())
+
+		err := logger.Reconfigure(log, logger.WithWriter(after), logger.WithJSON(true), logger.WithLevel("info"))
+		assert.NoError(t, err)
+
+		derived.Info("hello")
+		assert.Empty(t, before.String())
+		assert.Contains(t, after.String(), `"msg":"hello","k":"v"`)
+
+		err = logger.Reconfigure(slog.New(slog.NewTextHandler(after, nil)))
+		assert.Error(t, err)
+	})
+
+	t.Run("reconfigure applies options once", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(logger.WithWriter(buf), logger.WithLevel("warn"))
+
+		calls := 0
+		counting := func(cl *logger.CustomLogger) {
+			calls++
+		}
+
+		err := logger.Reconfigure(log, counting, logger.WithLevel("debug"))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+
+		log.Debug("hello")
+		assert.Contains(t, buf.String(), "msg=hello")
+
+		err = logger.Reconfigure(log, counting)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+
+		buf.Reset()
+		log.Debug("kept")
+		assert.Contains(t, buf.String(), "msg=kept")
+	})
+}
@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// flatAttr is an attr flattened with the groups it was added in
+type flatAttr struct {
+	key   string
+	value slog.Value
+}
+
+// flattenAttrs appends attrs to dst after replaceAttr, with their keys
+// qualified by groups joined with sep
+func flattenAttrs(dst []flatAttr, groups []string, attrs []slog.Attr, sep string, replaceAttr ReplaceAttrFunc) []flatAttr {
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+
+		if a.Value.Kind() == slog.KindGroup {
+			group := a.Value.Group()
+			if a.Key == "" {
+				dst = flattenAttrs(dst, groups, group, sep, replaceAttr)
+				continue
+			}
+
+			dst = flattenAttrs(dst, append(groups[:len(groups):len(groups)], a.Key), group, sep, replaceAttr)
+			continue
+		}
+
+		if replaceAttr != nil {
+			a = replaceAttr(groups, a)
+			a.Value = a.Value.Resolve()
+
+			// e.g. a rendered error, its attrs are not replaced again
+			if a.Value.Kind() == slog.KindGroup {
+				if a.Key != "" {
+					dst = flattenAttrs(dst, append(groups[:len(groups):len(groups)], a.Key), a.Value.Group(), sep, nil)
+				} else {
+					dst = flattenAttrs(dst, groups, a.Value.Group(), sep, nil)
+				}
+				continue
+			}
+		}
+
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+
+		key := a.Key
+		if len(groups) > 0 {
+			key = strings.Join(groups, sep) + sep + key
+		}
+
+		dst = append(dst, flatAttr{key: key, value: a.Value})
+	}
+
+	return dst
+}
+
+// replaceMessage returns the message after replaceAttr, e.g. redacted, for
+// the handlers writing it in a field of their own
+func replaceMessage(replaceAttr ReplaceAttrFunc, msg string) string {
+	if replaceAttr == nil {
+		return msg
+	}
+
+	a := replaceAttr(nil, slog.String(slog.MessageKey, msg))
+	if a.Equal(slog.Attr{}) {
+		return ""
+	}
+
+	return a.Value.Resolve().String()
+}
+
+// jsonEncoder renders records as JSON objects with slog.JSONHandler, for the
+// sinks sending them elsewhere than to an io.Writer
+type jsonEncoder struct {
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+	h   slog.Handler
+}
+
+func newJSONEncoder(opts *slog.HandlerOptions) *jsonEncoder {
+	var options slog.HandlerOptions
+	if opts != nil {
+		options = *opts
+	}
+
+	// the sinks check the level themselves
+	options.Level = levelAll
+	options.ReplaceAttr = withLevelName(options.ReplaceAttr)
+
+	buf := &bytes.Buffer{}
+
+	return &jsonEncoder{
+		mu:  &sync.Mutex{},
+		buf: buf,
+		h:   slog.NewJSONHandler(buf, &options),
+	}
+}
+
+// encode returns the record as a JSON object, without the trailing newline
+func (e *jsonEncoder) encode(ctx context.Context, rec slog.Record) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.buf.Reset()
+	if err := e.h.Handle(ctx, rec); err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(bytes.TrimSuffix(e.buf.Bytes(), []byte("\n"))), nil
+}
+
+func (e *jsonEncoder) withAttrs(attrs []slog.Attr) *jsonEncoder {
+	return &jsonEncoder{mu: e.mu, buf: e.buf, h: e.h.WithAttrs(attrs)}
+}
+
+func (e *jsonEncoder) withGroup(name string) *jsonEncoder {
+	return &jsonEncoder{mu: e.mu, buf: e.buf, h: e.h.WithGroup(name)}
+}
+
+// handlerLevel returns the minimum level of opts, INFO like the slog handlers
+// when none is set
+func handlerLevel(opts *slog.HandlerOptions) slog.Leveler {
+	if opts == nil || opts.Level == nil {
+		return slog.LevelInfo
+	}
+
+	return opts.Level
+}
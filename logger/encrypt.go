@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/devshansharma/tools/crypt"
+)
+
+// encryptedPrefix marks encrypted values in the output
+const encryptedPrefix = "enc:"
+
+var encryptedPattern = regexp.MustCompile(`"?enc:[0-9a-f]+"?`)
+
+// sensitive marks a value for NewEncryptor. Without an encryptor it is
+// rendered as [REDACTED], never in clear.
+type sensitive struct {
+	value any
+}
+
+func (s sensitive) String() string {
+	return "[REDACTED]"
+}
+
+func (s sensitive) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Sensitive marks v to be encrypted by NewEncryptor, e.g.
+// log.Info("refund", "customer_id", logger.Sensitive(id))
+func Sensitive(v any) slog.Value {
+	return slog.AnyValue(sensitive{value: v})
+}
+
+// EncryptOption to configure NewEncryptor
+type EncryptOption func(e *encryptor)
+
+type encryptor struct {
+	key  []byte
+	keys map[string]bool
+}
+
+// WithEncryptKeys for encrypting the values of these keys, matched
+// case-insensitively, in addition to the values marked with Sensitive
+func WithEncryptKeys(keys ...string) EncryptOption {
+	return func(e *encryptor) {
+		for _, k := range keys {
+			e.keys[strings.ToLower(k)] = true
+		}
+	}
+}
+
+// NewEncryptor returns a ReplaceAttrFunc encrypting the values marked with
+// Sensitive, and those of WithEncryptKeys, with crypt.EncryptAESGCM. They are
+// logged as "enc:" followed by the hex encoded ciphertext of their JSON
+// encoding, see DecryptValue. The key must be 16, 24 or 32 bytes long.
+// Put it first in a ChainReplaceAttr so the values are not redacted before.
+func NewEncryptor(key []byte, opts ...EncryptOption) (ReplaceAttrFunc, error) {
+	if _, err := crypt.EncryptAESGCM(key, nil); err != nil {
+		return nil, err
+	}
+
+	e := &encryptor{key: key, keys: map[string]bool{}}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e.replaceAttr, nil
+}
+
+func (e *encryptor) replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	var value any
+	var s sensitive
+	var marked bool
+
+	// Any boxes the other kinds
+	if a.Value.Kind() == slog.KindAny {
+		s, marked = a.Value.Any().(sensitive)
+	}
+
+	if marked {
+		value = s.value
+	} else if e.keys[strings.ToLower(a.Key)] {
+		value = a.Value.Any()
+	} else {
+		return a
+	}
+
+	a.Value = slog.StringValue(e.encrypt(value))
+
+	return a
+}
+
+// encrypt returns the encrypted value, or [REDACTED] when it can't be
+// encrypted, so that it never leaks
+func (e *encryptor) encrypt(v any) string {
+	if lv, ok := v.(slog.LogValuer); ok {
+		v = slog.AnyValue(lv).Resolve().Any()
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "[REDACTED]"
+	}
+
+	ciphertext, err := crypt.EncryptAESGCM(e.key, data)
+	if err != nil {
+		return "[REDACTED]"
+	}
+
+	return encryptedPrefix + ciphertext
+}
+
+// DecryptValue decrypts a value logged by NewEncryptor, returning it as
+// decoded from JSON, e.g. a string or a float64
+func DecryptValue(key []byte, s string) (any, error) {
+	ciphertext, ok := strings.CutPrefix(s, encryptedPrefix)
+	if !ok {
+		return nil, fmt.Errorf("value is not encrypted")
+	}
+
+	plaintext, err := crypt.DecryptAESGCM(key, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(plaintext), &v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// DecryptLine replaces the encrypted values of a JSON or text log line with
+// their JSON encoded plaintext, keeping the rest of the line as is. Values
+// that can't be decrypted are kept and their errors returned.
+func DecryptLine(key []byte, line string) (string, error) {
+	var errs []error
+
+	out := encryptedPattern.ReplaceAllStringFunc(line, func(m string) string {
+		ciphertext := strings.Trim(m, `"`)
+
+		plaintext, err := crypt.DecryptAESGCM(key, strings.TrimPrefix(ciphertext, encryptedPrefix))
+		if err != nil {
+			errs = append(errs, err)
+			return m
+		}
+
+		return plaintext
+	})
+
+	if len(errs) > 0 {
+		return out, fmt.Errorf("%d values not decrypted: %w", len(errs), errs[0])
+	}
+
+	return out, nil
+}
@@ -0,0 +1,79 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/crypt"
+	"github.com/devshansharma/tools/logger"
+)
+
+func TestEncryptor(t *testing.T) {
+	key, err := crypt.GenerateAES256Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := logger.NewEncryptor(key, logger.WithEncryptKeys("Email"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(
+		logger.WithWriter(buf),
+		logger.WithJSON(true),
+		logger.WithLevel("info"),
+		logger.WithReplaceAttr(logger.ChainReplaceAttr(enc, logger.NewRedactor())),
+	)
+
+	log.Info("refund",
+		"customer_id", logger.Sensitive("cus_123"),
+		slog.Group("customer", "email", "jane@example.com", "tier", logger.Sensitive(3)),
+		"amount", 10,
+	)
+
+	line := strings.TrimSpace(buf.String())
+	assert.NotContains(t, line, "cus_123")
+	assert.NotContains(t, line, "jane@example.com")
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+	assert.Equal(t, float64(10), entry["amount"])
+
+	id, err := logger.DecryptValue(key, entry["customer_id"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, "cus_123", id)
+
+	tier, err := logger.DecryptValue(key, entry["customer"].(map[string]any)["tier"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, float64(3), tier)
+
+	decrypted, err := logger.DecryptLine(key, line)
+	assert.NoError(t, err)
+	assert.Contains(t, decrypted, `"customer_id":"cus_123"`)
+	assert.Contains(t, decrypted, `"email":"jane@example.com","tier":3`)
+
+	other, _ := crypt.GenerateAES256Key()
+	kept, err := logger.DecryptLine(other, line)
+	assert.Error(t, err)
+	assert.Equal(t, line, kept)
+
+	_, err = logger.NewEncryptor([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestSensitiveWithoutEncryptor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(logger.WithWriter(buf), logger.WithLevel("info"))
+
+	log.Info("refund", "customer_id", logger.Sensitive("cus_123"))
+
+	assert.Contains(t, buf.String(), "customer_id=[REDACTED]")
+	assert.NotContains(t, buf.String(), "cus_123")
+}
@@ -0,0 +1,310 @@
+package logger
+
+import (
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mdobak/go-xerrors"
+)
+
+// ErrorTraceOption to configure NewErrorTrace
+type ErrorTraceOption func(e *errorTrace)
+
+type errorTrace struct {
+	maxDepth   int
+	maxFrames  int
+	skipFrames []string
+}
+
+// WithMaxDepth for how many causes deep errors are rendered, default is 8
+func WithMaxDepth(n int) ErrorTraceOption {
+	return func(e *errorTrace) {
+		e.maxDepth = n
+	}
+}
+
+// WithMaxFrames for the number of stack frames kept per trace, default is 32
+func WithMaxFrames(n int) ErrorTraceOption {
+	return func(e *errorTrace) {
+		e.maxFrames = n
+	}
+}
+
+// NewErrorTrace returns a ReplaceAttrFunc rendering error values as a group
+// with keys
+//   - `msg` and `type`, the concrete type of the error
+//   - `attrs`, from slog.LogValuer errors or exported fields of error structs
+//   - `trace`, when the error carries a go-xerrors or pkg/errors stack trace,
+//     without runtime, testing, log/slog and logger frames, see WithSkipFrames
+//   - `cause` for a wrapped error, or `causes` for errors.Join and alike
+//
+// Wrappers that don't change the message, such as the ones adding a stack
+// trace, are merged with the error they wrap.
+func NewErrorTrace(opts ...ErrorTraceOption) ReplaceAttrFunc {
+	e := newErrorTrace(opts...)
+
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if a.Value.Kind() == slog.KindAny {
+			if err, ok := a.Value.Any().(error); ok && err != nil {
+				a.Value = e.fmtErr(err, 1)
+			}
+		}
+
+		return a
+	}
+}
+
+func newErrorTrace(opts ...ErrorTraceOption) *errorTrace {
+	e := &errorTrace{
+		maxDepth:   8,
+		maxFrames:  32,
+		skipFrames: defaultSkipFrames,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+var defaultErrorTrace = NewErrorTrace()
+
+// WithErrorTrace for showing error trace in logs
+func WithErrorTrace(groups []string, a slog.Attr) slog.Attr {
+	return defaultErrorTrace(groups, a)
+}
+
+type stackFrame struct {
+	Func   string `json:"func"`
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+}
+
+// fmtErr renders err and its causes, see NewErrorTrace
+func (e *errorTrace) fmtErr(err error, depth int) slog.Value {
+	msg := err.Error()
+
+	var trace []stackFrame
+	var fields []slog.Attr
+
+	for {
+		if trace == nil {
+			trace = e.ownStack(err)
+		}
+		fields = append(fields, errorFields(err)...)
+
+		next := errors.Unwrap(err)
+		if next == nil || next.Error() != msg {
+			break
+		}
+		err = next
+	}
+
+	// msg, type, attrs, trace and cause or causes
+	groupValues := make([]slog.Attr, 2, 5)
+	groupValues[0] = slog.String("msg", msg)
+	groupValues[1] = slog.String("type", typeName(err))
+
+	if len(fields) > 0 {
+		groupValues = append(groupValues, slog.Attr{Key: "attrs", Value: slog.GroupValue(fields...)})
+	}
+
+	if trace != nil {
+		groupValues = append(groupValues, slog.Any("trace", trace))
+	}
+
+	causes := unwrapAll(err)
+	if len(causes) > 0 && depth >= e.maxDepth {
+		return slog.GroupValue(append(groupValues, slog.Bool("truncated", true))...)
+	}
+
+	switch len(causes) {
+	case 0:
+	case 1:
+		if _, multi := err.(interface{ Unwrap() []error }); !multi {
+			groupValues = append(groupValues, slog.Attr{Key: "cause", Value: e.fmtErr(causes[0], depth+1)})
+			break
+		}
+		fallthrough
+	default:
+		group := make([]slog.Attr, len(causes))
+		for i, cause := range causes {
+			group[i] = slog.Attr{Key: strconv.Itoa(i), Value: e.fmtErr(cause, depth+1)}
+		}
+		groupValues = append(groupValues, slog.Attr{Key: "causes", Value: slog.GroupValue(group...)})
+	}
+
+	return slog.GroupValue(groupValues...)
+}
+
+// ownStack returns the trace carried by err itself, not by the errors it wraps
+func (e *errorTrace) ownStack(err error) []stackFrame {
+	return e.frames(stackOf(err))
+}
+
+// unwrapAll returns the errors wrapped by err, for single and multi wrappers
+func unwrapAll(err error) []error {
+	var causes []error
+
+	switch v := err.(type) {
+	case interface{ Unwrap() []error }:
+		causes = v.Unwrap()
+	case xerrors.MultiError:
+		causes = v.Errors()
+	default:
+		if cause := errors.Unwrap(err); cause != nil {
+			causes = []error{cause}
+		}
+	}
+
+	if !slices.Contains(causes, nil) {
+		return causes
+	}
+
+	out := causes[:0:0]
+	for _, c := range causes {
+		if c != nil {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+// errorFields returns the attrs of a slog.LogValuer error, or the exported
+// fields of simple kinds of an error struct, e.g. Op and Path of *fs.PathError
+func errorFields(err error) []slog.Attr {
+	if lv, ok := err.(slog.LogValuer); ok {
+		v := lv.LogValue().Resolve()
+		if v.Kind() == slog.KindGroup {
+			return v.Group()
+		}
+
+		return []slog.Attr{{Key: "value", Value: v}}
+	}
+
+	rv := reflect.ValueOf(err)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := structFields(rv.Type())
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var attrs []slog.Attr
+	for _, f := range fields {
+		if v, ok := simpleValue(rv.Field(f.index)); ok {
+			attrs = append(attrs, slog.Attr{Key: f.name, Value: v})
+		}
+	}
+
+	return attrs
+}
+
+type structField struct {
+	index int
+	name  string
+}
+
+// typeNames and errorStructs cache the names and exported fields of error
+// types, most errors logged are of a handful of types
+var (
+	typeNames    sync.Map
+	errorStructs sync.Map
+)
+
+// typeName returns the type of err like %T
+func typeName(err error) string {
+	t := reflect.TypeOf(err)
+	if name, ok := typeNames.Load(t); ok {
+		return name.(string)
+	}
+
+	name := t.String()
+	typeNames.Store(t, name)
+
+	return name
+}
+
+// structFields returns the exported fields of t, a struct
+func structFields(t reflect.Type) []structField {
+	if fields, ok := errorStructs.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() {
+			fields = append(fields, structField{index: i, name: f.Name})
+		}
+	}
+
+	errorStructs.Store(t, fields)
+
+	return fields
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// simpleValue converts basic kinds, leaving wrapped errors and complex
+// values out of the rendered attrs
+func simpleValue(v reflect.Value) (slog.Value, bool) {
+	switch v.Type() {
+	case timeType:
+		return slog.TimeValue(v.Interface().(time.Time)), true
+	case durationType:
+		return slog.DurationValue(time.Duration(v.Int())), true
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return slog.StringValue(v.String()), true
+	case reflect.Bool:
+		return slog.BoolValue(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return slog.Int64Value(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return slog.Uint64Value(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return slog.Float64Value(v.Float()), true
+	}
+
+	return slog.Value{}, false
+}
+
+// marshalStack extracts stack frames from the first error in the chain
+// carrying a trace
+func marshalStack(err error) []stackFrame {
+	return newErrorTrace().frames(chainStack(err))
+}
+
+func newStackFrame(f runtime.Frame) stackFrame {
+	return stackFrame{
+		Source: filepath.Join(
+			filepath.Base(filepath.Dir(f.File)),
+			filepath.Base(f.File),
+		),
+		Func: filepath.Base(f.Function),
+		Line: f.Line,
+	}
+}
@@ -0,0 +1,187 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mdobak/go-xerrors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+type quotaError struct {
+	Tenant string
+	Limit  int
+}
+
+func (q quotaError) Error() string {
+	return "quota exceeded"
+}
+
+type valuerError struct{}
+
+func (valuerError) Error() string {
+	return "payment declined"
+}
+
+func (valuerError) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("provider", "acme"), slog.Int("code", 51))
+}
+
+// frame and pkgError follow the pkg/errors stack trace convention
+type frame uintptr
+
+type pkgError struct {
+	stack []frame
+}
+
+func newPkgError() pkgError {
+	pcs := make([]uintptr, 32)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+
+	e := pkgError{stack: make([]frame, len(pcs))}
+	for i, pc := range pcs {
+		e.stack[i] = frame(pc)
+	}
+
+	return e
+}
+
+func (pkgError) Error() string {
+	return "pkg error"
+}
+
+func (e pkgError) StackTrace() []frame {
+	return e.stack
+}
+
+func frameFuncs(trace any) []string {
+	var funcs []string
+	for _, f := range trace.([]any) {
+		funcs = append(funcs, f.(map[string]any)["func"].(string))
+	}
+
+	return funcs
+}
+
+func logError(t *testing.T, f logger.ReplaceAttrFunc, err error) map[string]any {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(logger.WithWriter(buf), logger.WithJSON(true), logger.WithReplaceAttr(f))
+	log.Error("failed", slog.Any("error", err))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	return entry["error"].(map[string]any)
+}
+
+func TestErrorTrace(t *testing.T) {
+	t.Run("xerrors trace", func(t *testing.T) {
+		rendered := logError(t, logger.WithErrorTrace, xerrors.New("something happened"))
+
+		assert.Equal(t, "something happened", rendered["msg"])
+		assert.NotEmpty(t, rendered["trace"])
+		assert.Nil(t, rendered["cause"])
+	})
+
+	t.Run("wrapped chain", func(t *testing.T) {
+		_, openErr := os.Open("/does/not/exist")
+		err := fmt.Errorf("loading config: %w", openErr)
+
+		rendered := logError(t, logger.WithErrorTrace, err)
+
+		assert.Equal(t, "*fmt.wrapError", rendered["type"])
+		cause := rendered["cause"].(map[string]any)
+		assert.Equal(t, "*fs.PathError", cause["type"])
+		assert.Equal(t, map[string]any{"Op": "open", "Path": "/does/not/exist"}, cause["attrs"])
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+		assert.Equal(t, "syscall.Errno", cause["cause"].(map[string]any)["type"])
+	})
+
+	t.Run("joined errors", func(t *testing.T) {
+		err := errors.Join(quotaError{Tenant: "acme", Limit: 10}, valuerError{})
+
+		rendered := logError(t, logger.WithErrorTrace, err)
+
+		causes := rendered["causes"].(map[string]any)
+		assert.Equal(t, map[string]any{"Tenant": "acme", "Limit": float64(10)}, causes["0"].(map[string]any)["attrs"])
+		assert.Equal(t, map[string]any{"provider": "acme", "code": float64(51)}, causes["1"].(map[string]any)["attrs"])
+	})
+
+	t.Run("depth and frames caps", func(t *testing.T) {
+		err := xerrors.New("root")
+		for i := 0; i < 5; i++ {
+			err = fmt.Errorf("level %d: %w", i, err)
+		}
+
+		rendered := logError(t, logger.NewErrorTrace(logger.WithMaxDepth(3)), err)
+		second := rendered["cause"].(map[string]any)["cause"].(map[string]any)
+		assert.Equal(t, true, second["truncated"])
+		assert.Nil(t, second["cause"])
+
+		rendered = logError(t, logger.NewErrorTrace(logger.WithMaxFrames(1)), xerrors.New("root"))
+		assert.Len(t, rendered["trace"], 1)
+	})
+
+	t.Run("pkg/errors trace", func(t *testing.T) {
+		rendered := logError(t, logger.WithErrorTrace, fmt.Errorf("wrapped: %w", newPkgError()))
+
+		funcs := frameFuncs(rendered["cause"].(map[string]any)["trace"])
+		assert.Contains(t, funcs[0], "logger_test.TestErrorTrace")
+	})
+
+	t.Run("runtime and testing frames skipped", func(t *testing.T) {
+		rendered := logError(t, logger.WithErrorTrace, xerrors.New("root"))
+		for _, f := range frameFuncs(rendered["trace"]) {
+			assert.False(t, strings.HasPrefix(f, "runtime.") || strings.HasPrefix(f, "testing."), f)
+		}
+
+		rendered = logError(t, logger.NewErrorTrace(logger.WithAllFrames()), xerrors.New("root"))
+		assert.Contains(t, frameFuncs(rendered["trace"]), "runtime.goexit")
+
+		rendered = logError(t, logger.NewErrorTrace(logger.WithSkipFrames("github.com/devshansharma/tools/logger_test.")), newPkgError())
+		assert.Nil(t, rendered["trace"])
+	})
+}
+
+func TestCaptureStack(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(
+		logger.WithWriter(buf),
+		logger.WithJSON(true),
+		logger.WithLevel("debug"),
+		logger.WithCaptureStack(slog.LevelError),
+	)
+
+	entry := func() map[string]any {
+		defer buf.Reset()
+
+		var e map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		return e
+	}
+
+	log.Error("failed", slog.Any("error", errors.New("plain")))
+	funcs := frameFuncs(entry()["stack"])
+	assert.Equal(t, "logger_test.TestCaptureStack", funcs[0])
+
+	log.Warn("slow")
+	assert.Nil(t, entry()["stack"])
+
+	log.Error("failed", slog.Group("req", slog.Any("error", xerrors.New("traced"))))
+	assert.Nil(t, entry()["stack"])
+}
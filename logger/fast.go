@@ -0,0 +1,484 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FastOption to configure FastHandler
+type FastOption func(h *FastHandler)
+
+// WithShortSource for source file names without their directory, like
+// WithShortFileName but computed once per call site
+func WithShortSource(b bool) FastOption {
+	return func(h *FastHandler) {
+		h.shortSource = b
+	}
+}
+
+// WithFastJSON for writing JSON records with FastHandler instead of
+// slog.JSONHandler, the output is the same
+func WithFastJSON(b bool) func(*CustomLogger) {
+	return func(cl *CustomLogger) {
+		cl.fastJSON = b
+	}
+}
+
+// FastHandler writes records as JSON, with the same output as
+// slog.JSONHandler followed by the level names of LevelName. It is meant for
+// the hot paths logging on every request:
+//   - records are encoded into pooled buffers, without reflection for the
+//     attrs of the basic kinds and for errors
+//   - sources are resolved once per call site and cached
+//   - attrs added with WithAttrs are encoded once
+//
+// A ReplaceAttr function is supported, but the source is then copied for
+// every record, so that it can be changed.
+type FastHandler struct {
+	opts        slog.HandlerOptions
+	shortSource bool
+
+	mu *sync.Mutex
+	w  io.Writer
+
+	// preformatted holds the attrs of WithAttrs, opened groups included
+	preformatted []byte
+	groups       []string
+	// opened is the number of groups opened in preformatted
+	opened int
+}
+
+// NewFastHandler creates a FastHandler writing to w, opts are used like by
+// slog.NewJSONHandler
+func NewFastHandler(w io.Writer, opts *slog.HandlerOptions, fopts ...FastOption) *FastHandler {
+	h := &FastHandler{mu: &sync.Mutex{}, w: w}
+	if opts != nil {
+		h.opts = *opts
+	}
+
+	for _, opt := range fopts {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *FastHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= handlerLevel(&h.opts).Level()
+}
+
+func (h *FastHandler) Handle(_ context.Context, rec slog.Record) error {
+	bp := getBuffer()
+	defer putBuffer(bp)
+
+	b := append(*bp, '{')
+
+	rep := h.opts.ReplaceAttr
+	if rep == nil {
+		if !rec.Time.IsZero() {
+			b = append(b, `"time":"`...)
+			b = rec.Time.AppendFormat(b, time.RFC3339Nano)
+			b = append(b, '"', ',')
+		}
+
+		b = append(b, `"level":`...)
+		b = appendJSONString(b, LevelName(rec.Level))
+
+		if h.opts.AddSource && rec.PC != 0 {
+			b = append(b, `,"source":`...)
+			b = appendSource(b, h.source(rec.PC))
+		}
+
+		b = append(b, `,"msg":`...)
+		b = appendJSONString(b, rec.Message)
+	} else {
+		if !rec.Time.IsZero() {
+			b = h.appendAttr(b, nil, slog.Time(slog.TimeKey, rec.Time.Round(0)))
+		}
+
+		b = h.appendAttr(b, nil, slog.Any(slog.LevelKey, rec.Level))
+
+		if h.opts.AddSource && rec.PC != 0 {
+			src := *h.source(rec.PC)
+			b = h.appendAttr(b, nil, slog.Any(slog.SourceKey, &src))
+		}
+
+		b = h.appendAttr(b, nil, slog.String(slog.MessageKey, rec.Message))
+	}
+
+	b = append(b, h.preformatted...)
+
+	if rec.NumAttrs() > 0 {
+		for _, g := range h.groups[h.opened:] {
+			b = appendSep(b)
+			b = appendJSONString(b, g)
+			b = append(b, ':', '{')
+		}
+
+		rec.Attrs(func(a slog.Attr) bool {
+			b = h.appendAttr(b, h.groups, a)
+			return true
+		})
+
+		for range h.groups[h.opened:] {
+			b = append(b, '}')
+		}
+	}
+
+	for range h.groups[:h.opened] {
+		b = append(b, '}')
+	}
+
+	b = append(b, '}', '\n')
+	*bp = b
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := h.w.Write(b)
+
+	return err
+}
+
+func (h *FastHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	c := *h
+	b := bytes.Clone(h.preformatted)
+
+	for _, g := range h.groups[h.opened:] {
+		b = appendSep(b)
+		b = appendJSONString(b, g)
+		b = append(b, ':', '{')
+	}
+
+	for _, a := range attrs {
+		b = h.appendAttr(b, h.groups, a)
+	}
+
+	c.preformatted = b
+	c.opened = len(h.groups)
+
+	return &c
+}
+
+func (h *FastHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	c := *h
+	c.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+
+	return &c
+}
+
+// sources caches the source of each call site
+var sources sync.Map
+
+type sourceKey struct {
+	pc    uintptr
+	short bool
+}
+
+func (h *FastHandler) source(pc uintptr) *slog.Source {
+	key := sourceKey{pc: pc, short: h.shortSource}
+	if src, ok := sources.Load(key); ok {
+		return src.(*slog.Source)
+	}
+
+	f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+
+	src := &slog.Source{Function: f.Function, File: f.File, Line: f.Line}
+	if h.shortSource {
+		src.File = filepath.Base(src.File)
+	}
+
+	actual, _ := sources.LoadOrStore(key, src)
+
+	return actual.(*slog.Source)
+}
+
+// appendAttr appends a, after ReplaceAttr, as a member of an object
+func (h *FastHandler) appendAttr(b []byte, groups []string, a slog.Attr) []byte {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return b
+		}
+
+		if a.Key == "" {
+			for _, ga := range attrs {
+				b = h.appendAttr(b, groups, ga)
+			}
+			return b
+		}
+
+		b = appendSep(b)
+		b = appendJSONString(b, a.Key)
+		b = append(b, ':', '{')
+
+		groups = append(groups[:len(groups):len(groups)], a.Key)
+		for _, ga := range attrs {
+			b = h.appendAttr(b, groups, ga)
+		}
+
+		return append(b, '}')
+	}
+
+	// the level is named like replaceLevelName does, even when renamed
+	level := a.Key == slog.LevelKey && len(groups) == 0
+
+	if h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+
+		// the replaced value may be a group, e.g. a rendered error
+		if a.Value.Kind() == slog.KindGroup {
+			return h.appendGroup(b, a)
+		}
+	}
+
+	if a.Equal(slog.Attr{}) {
+		return b
+	}
+
+	b = appendSep(b)
+	b = appendJSONString(b, a.Key)
+	b = append(b, ':')
+
+	return appendJSONValue(b, a.Value, level)
+}
+
+// appendGroup appends a group returned by ReplaceAttr, ReplaceAttr is not
+// called again on its members
+func (h *FastHandler) appendGroup(b []byte, a slog.Attr) []byte {
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return b
+	}
+
+	if a.Key != "" {
+		b = appendSep(b)
+		b = appendJSONString(b, a.Key)
+		b = append(b, ':', '{')
+	}
+
+	for _, ga := range attrs {
+		ga.Value = ga.Value.Resolve()
+
+		if ga.Value.Kind() == slog.KindGroup {
+			b = h.appendGroup(b, ga)
+			continue
+		}
+
+		if ga.Equal(slog.Attr{}) {
+			continue
+		}
+
+		b = appendSep(b)
+		b = appendJSONString(b, ga.Key)
+		b = append(b, ':')
+		b = appendJSONValue(b, ga.Value, false)
+	}
+
+	if a.Key != "" {
+		b = append(b, '}')
+	}
+
+	return b
+}
+
+// appendSep separates object members, b always follows an opened object
+// or a member
+func appendSep(b []byte) []byte {
+	if len(b) > 0 && b[len(b)-1] == '{' {
+		return b
+	}
+
+	return append(b, ',')
+}
+
+func appendSource(b []byte, src *slog.Source) []byte {
+	b = append(b, `{"function":`...)
+	b = appendJSONString(b, src.Function)
+	b = append(b, `,"file":`...)
+	b = appendJSONString(b, src.File)
+	b = append(b, `,"line":`...)
+	b = strconv.AppendInt(b, int64(src.Line), 10)
+
+	return append(b, '}')
+}
+
+// appendJSONValue appends a value that is not a group. Levels of the level
+// attr are named by LevelName, the others like slog.JSONHandler does.
+func appendJSONValue(b []byte, v slog.Value, level bool) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendJSONString(b, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(b, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(b, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return appendJSONFloat(b, v.Float64())
+	case slog.KindBool:
+		return strconv.AppendBool(b, v.Bool())
+	case slog.KindDuration:
+		return strconv.AppendInt(b, int64(v.Duration()), 10)
+	case slog.KindTime:
+		b = append(b, '"')
+		b = v.Time().AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"')
+	}
+
+	switch a := v.Any().(type) {
+	case nil:
+		return append(b, "null"...)
+	case slog.Level:
+		if level {
+			return appendJSONString(b, LevelName(a))
+		}
+		return appendJSONString(b, a.String())
+	case *slog.Source:
+		return appendSource(b, a)
+	case json.Marshaler:
+	case error:
+		return appendJSONString(b, a.Error())
+	}
+
+	return appendJSONMarshal(b, v.Any())
+}
+
+// appendJSONFloat formats f like encoding/json, which slog.JSONHandler
+// uses for floats, and NaN and infinities like the error it gets for them
+func appendJSONFloat(b []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(b, "!ERROR:json: unsupported value: "+strconv.FormatFloat(f, 'g', -1, 64))
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+
+	// e-07 to e-7
+	if n := len(b); format == 'e' && n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+		b[n-2] = b[n-1]
+		b = b[:n-1]
+	}
+
+	return b
+}
+
+func appendJSONMarshal(b []byte, v any) []byte {
+	buf := bytes.NewBuffer(b)
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		return appendJSONString(b, "!ERROR:"+err.Error())
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'})
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s quoted, escaped like encoding/json without
+// the HTML escaping, as slog.JSONHandler does
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+
+		i += size
+	}
+
+	b = append(b, s[start:]...)
+
+	return append(b, '"')
+}
+
+// bufferPool holds the buffers records are encoded in, large ones are not
+// kept so that a burst of big records doesn't pin memory
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+const maxPooledBuffer = 64 << 10
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBuffer {
+		return
+	}
+
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
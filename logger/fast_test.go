@@ -0,0 +1,229 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+type point struct {
+	X, Y int
+}
+
+type userID string
+
+func (u userID) LogValue() slog.Value {
+	return slog.StringValue("user-" + string(u))
+}
+
+func TestFastHandler(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+
+	// the reference output is the one of slog.JSONHandler with the level
+	// names of the logger package
+	reference := func(buf *bytes.Buffer, opts slog.HandlerOptions) slog.Handler {
+		replaceAttr := opts.ReplaceAttr
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			level := a.Key == slog.LevelKey && len(groups) == 0
+			if replaceAttr != nil {
+				a = replaceAttr(groups, a)
+			}
+			if l, ok := a.Value.Any().(slog.Level); ok && level {
+				a.Value = slog.StringValue(logger.LevelName(l))
+			}
+			return a
+		}
+
+		return slog.NewJSONHandler(buf, &opts)
+	}
+
+	cases := []struct {
+		name  string
+		opts  slog.HandlerOptions
+		level slog.Level
+		with  func(h slog.Handler) slog.Handler
+		attrs []slog.Attr
+	}{
+		{
+			name: "basic kinds",
+			attrs: []slog.Attr{
+				slog.String("s", "quote \" backslash \\ newline \n tab \t ctrl \x01 <html> &   é \xff"),
+				slog.Int("i", -42),
+				slog.Uint64("u", 42),
+				slog.Float64("f", 1.5),
+				slog.Float64("big", 1e21),
+				slog.Bool("b", true),
+				slog.Duration("d", 1500*time.Millisecond),
+				slog.Time("t", now),
+				slog.Any("nil", nil),
+			},
+		},
+		{
+			name: "any values",
+			attrs: []slog.Attr{
+				slog.Any("err", errors.New("boom")),
+				slog.Any("struct", point{1, 2}),
+				slog.Any("map", map[string]int{"a": 1}),
+				slog.Any("bytes", []byte("hi")),
+				slog.Any("valuer", userID("42")),
+				slog.Any("level", slog.LevelWarn),
+				slog.Any("min_level", logger.LevelTrace),
+			},
+		},
+		{
+			name:  "levels",
+			level: logger.LevelFatal,
+			attrs: []slog.Attr{slog.Group("g", slog.Any("level", logger.LevelTrace))},
+		},
+		{
+			name: "groups",
+			with: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("service", "orders")}).
+					WithGroup("req").
+					WithAttrs([]slog.Attr{slog.String("id", "r1")}).
+					WithGroup("http")
+			},
+			attrs: []slog.Attr{
+				slog.Int("status", 200),
+				slog.Group("empty"),
+				slog.Group("", slog.String("inlined", "yes")),
+				slog.Group("headers", slog.String("accept", "*/*")),
+			},
+		},
+		{
+			name: "empty group without attrs",
+			with: func(h slog.Handler) slog.Handler {
+				return h.WithGroup("req")
+			},
+		},
+		{
+			name: "replace attr",
+			opts: slog.HandlerOptions{
+				ReplaceAttr: logger.ChainReplaceAttr(
+					logger.RenameKey(slog.MessageKey, "message"),
+					logger.RenameKey(slog.LevelKey, "severity"),
+					logger.DropKeys("secret", "req.token"),
+					logger.WithErrorTrace,
+				),
+			},
+			with: func(h slog.Handler) slog.Handler {
+				return h.WithGroup("req")
+			},
+			attrs: []slog.Attr{
+				slog.String("secret", "x"),
+				slog.String("token", "y"),
+				slog.String("path", "/"),
+				slog.Any("error", errors.Join(errors.New("a"), errors.New("b"))),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			want, got := &bytes.Buffer{}, &bytes.Buffer{}
+
+			var ref, fast slog.Handler = reference(want, c.opts), logger.NewFastHandler(got, &c.opts)
+			if c.with != nil {
+				ref, fast = c.with(ref), c.with(fast)
+			}
+
+			level := c.level
+			if level == 0 {
+				level = slog.LevelInfo
+			}
+
+			rec := slog.NewRecord(now, level, "hello \"world\"", 0)
+			rec.AddAttrs(c.attrs...)
+
+			assert.NoError(t, ref.Handle(ctx, rec))
+			assert.NoError(t, fast.Handle(ctx, rec))
+			assert.Equal(t, want.String(), got.String())
+		})
+	}
+
+	t.Run("source", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		log := slog.New(logger.NewFastHandler(buf, &slog.HandlerOptions{AddSource: true}, logger.WithShortSource(true)))
+		for i := 0; i < 2; i++ {
+			log.Info("cached")
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(t, lines, 2)
+		assert.Regexp(t, `"source":\{"function":"[^"]+TestFastHandler[^"]*","file":"fast_test.go","line":\d+\}`, lines[0])
+		assert.Equal(t, lines[0][strings.Index(lines[0], `"level"`):], lines[1][strings.Index(lines[1], `"level"`):])
+
+		buf.Reset()
+		log = slog.New(logger.NewFastHandler(buf, &slog.HandlerOptions{AddSource: true, ReplaceAttr: logger.WithShortFileName}))
+		log.Info("replaced")
+		log.Info("replaced")
+		assert.Contains(t, buf.String(), `"file":"fast_test.go"`)
+
+		buf.Reset()
+		log = slog.New(logger.NewFastHandler(buf, &slog.HandlerOptions{AddSource: true}))
+		log.Info("full path")
+		assert.Regexp(t, `"file":"/[^"]+/logger/fast_test.go"`, buf.String())
+	})
+
+	t.Run("levels", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		log := slog.New(logger.NewFastHandler(buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		log.Info("dropped")
+		log.Warn("kept")
+
+		assert.NotContains(t, buf.String(), "dropped")
+		assert.Contains(t, buf.String(), `"msg":"kept"`)
+	})
+
+	t.Run("same values as slog.JSONHandler", func(t *testing.T) {
+		values := []any{
+			0.0, math.Copysign(0, -1), 0.1, 1.5, -2.25, 1e6, 1e-6, 1e-7, -1e-7, 123456789.123,
+			1e20, 1e21, 1.5e21, 1e300, -1e300, math.MaxFloat64, math.SmallestNonzeroFloat64,
+			float32(0.1), float32(1e-7), math.NaN(), math.Inf(1), math.Inf(-1),
+			0, -1, math.MaxInt64, math.MinInt64, uint64(math.MaxUint64), int8(-8), uint16(16),
+			"", "text", true, false, time.Duration(0), -time.Second, now, time.Time{},
+			[]float64{1e6, 1e-7}, map[string]float64{"x": 1e21}, struct{ F float64 }{1e-9},
+		}
+
+		for _, v := range values {
+			want, got := &bytes.Buffer{}, &bytes.Buffer{}
+
+			rec := slog.NewRecord(now, slog.LevelInfo, "value", 0)
+			rec.AddAttrs(slog.Any("v", v))
+
+			assert.NoError(t, slog.NewJSONHandler(want, nil).Handle(ctx, rec))
+			assert.NoError(t, logger.NewFastHandler(got, nil).Handle(ctx, rec))
+			assert.Equal(t, want.String(), got.String(), "%T %v", v, v)
+		}
+	})
+
+	t.Run("through the logger", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		log := logger.NewLogger(
+			logger.WithWriter(buf),
+			logger.WithJSON(true),
+			logger.WithFastJSON(true),
+			logger.WithLevel("trace"),
+			logger.WithSource(true),
+			logger.WithReplaceAttr(logger.WithShortFileNameAndErrorTrace),
+		)
+		log.Log(ctx, logger.LevelTrace, "tracing", "error", errors.New("boom"))
+
+		assert.Contains(t, buf.String(), `"level":"TRACE"`)
+		assert.Contains(t, buf.String(), `"file":"fast_test.go"`)
+		assert.Contains(t, buf.String(), `"error":{"msg":"boom","type":"*errors.errorString"}`)
+	})
+}
@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JournalOption to configure JournalHandler
+type JournalOption func(j *journalConfig)
+
+type journalConfig struct {
+	socket     string
+	identifier string
+}
+
+// WithJournalSocket for the path of the journal socket, default is
+// /run/systemd/journal/socket
+func WithJournalSocket(path string) JournalOption {
+	return func(j *journalConfig) {
+		j.socket = path
+	}
+}
+
+// WithIdentifier for the SYSLOG_IDENTIFIER field, default is the name of the executable
+func WithIdentifier(name string) JournalOption {
+	return func(j *journalConfig) {
+		j.identifier = name
+	}
+}
+
+// JournalHandler sends records to the systemd journal over its native
+// protocol. Attrs become journal fields, with keys upper-cased and groups
+// joined with "_", e.g. REQ_PATH for the attr path in the group req.
+type JournalHandler struct {
+	conn   *net.UnixConn
+	config journalConfig
+	opts   slog.HandlerOptions
+	attrs  []flatAttr
+	groups []string
+}
+
+// NewJournalHandler connects to the journal socket, opts are used like by
+// slog.NewTextHandler
+func NewJournalHandler(opts *slog.HandlerOptions, jopts ...JournalOption) (*JournalHandler, error) {
+	j := &JournalHandler{
+		config: journalConfig{
+			socket:     "/run/systemd/journal/socket",
+			identifier: filepath.Base(os.Args[0]),
+		},
+	}
+
+	if opts != nil {
+		j.opts = *opts
+	}
+
+	for _, opt := range jopts {
+		opt(&j.config)
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: j.config.socket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to journal: %w", err)
+	}
+
+	j.conn = conn
+
+	return j, nil
+}
+
+func (j *JournalHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= handlerLevel(&j.opts).Level()
+}
+
+func (j *JournalHandler) Handle(_ context.Context, rec slog.Record) error {
+	var recAttrs []slog.Attr
+	rec.Attrs(func(a slog.Attr) bool {
+		recAttrs = append(recAttrs, a)
+		return true
+	})
+
+	buf := &bytes.Buffer{}
+
+	writeJournalField(buf, "MESSAGE", replaceMessage(j.opts.ReplaceAttr, rec.Message))
+	writeJournalField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(rec.Level)))
+	writeJournalField(buf, "SYSLOG_IDENTIFIER", j.config.identifier)
+	writeJournalField(buf, "LEVEL", LevelName(rec.Level))
+
+	if j.opts.AddSource && rec.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{rec.PC}).Next()
+		writeJournalField(buf, "CODE_FILE", f.File)
+		writeJournalField(buf, "CODE_LINE", strconv.Itoa(f.Line))
+		writeJournalField(buf, "CODE_FUNC", f.Function)
+	}
+
+	attrs := flattenAttrs(j.attrs[:len(j.attrs):len(j.attrs)], j.groups, recAttrs, "_", j.opts.ReplaceAttr)
+	for _, a := range attrs {
+		if key := journalKey(a.key); key != "" {
+			writeJournalField(buf, key, journalValue(a.value))
+		}
+	}
+
+	_, err := j.conn.Write(buf.Bytes())
+
+	return err
+}
+
+func (j *JournalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return j
+	}
+
+	c := *j
+	c.attrs = flattenAttrs(c.attrs[:len(c.attrs):len(c.attrs)], c.groups, attrs, "_", j.opts.ReplaceAttr)
+
+	return &c
+}
+
+func (j *JournalHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return j
+	}
+
+	c := *j
+	c.groups = append(c.groups[:len(c.groups):len(c.groups)], name)
+
+	return &c
+}
+
+// Close closes the connection to the journal
+func (j *JournalHandler) Close(context.Context) error {
+	return j.conn.Close()
+}
+
+// writeJournalField writes KEY=value, or the binary form for values with newlines
+func writeJournalField(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalKey returns a valid field name: upper-case letters, digits and
+// underscores, not starting with an underscore, at most 64 characters
+func journalKey(key string) string {
+	b := []byte(strings.ToUpper(key))
+	for i, c := range b {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+
+	s := strings.TrimLeft(string(b), "_")
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		s = "F_" + s
+	}
+
+	if len(s) > 64 {
+		s = s[:64]
+	}
+
+	return s
+}
+
+func journalValue(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		switch a := v.Any().(type) {
+		case error:
+			return a.Error()
+		case slog.Level:
+			return LevelName(a)
+		}
+
+		return fmt.Sprintf("%+v", v.Any())
+	}
+
+	return v.String()
+}
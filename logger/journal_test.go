@@ -0,0 +1,74 @@
+package logger_test
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+func TestJournalHandler(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "socket")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	h, err := logger.NewJournalHandler(nil, logger.WithJournalSocket(path), logger.WithIdentifier("billing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slog.New(h).WithGroup("req").Error("charge failed", "path", "/pay", "body", "line 1\nline 2", "2fa", true)
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multiline := "line 1\nline 2"
+	size := binary.LittleEndian.AppendUint64(nil, uint64(len(multiline)))
+
+	want := "MESSAGE=charge failed\n" +
+		"PRIORITY=3\n" +
+		"SYSLOG_IDENTIFIER=billing\n" +
+		"LEVEL=ERROR\n" +
+		"REQ_PATH=/pay\n" +
+		"REQ_BODY\n" + string(size) + multiline + "\n" +
+		"REQ_2FA=true\n"
+
+	assert.Equal(t, want, string(buf[:n]))
+
+	// the message goes through ReplaceAttr like the attrs
+	h, err = logger.NewJournalHandler(&slog.HandlerOptions{ReplaceAttr: logger.NewRedactor()}, logger.WithJournalSocket(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slog.New(h).Warn("reset sent to jane@example.com")
+
+	n, _, err = conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(buf[:n]), "MESSAGE=reset sent to [REDACTED]\n")
+
+	_, err = logger.NewJournalHandler(nil, logger.WithJournalSocket(filepath.Join(dir, "missing")))
+	assert.Error(t, err)
+}
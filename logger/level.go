@@ -0,0 +1,285 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// LevelTrace for very verbose logs, below DEBUG
+	LevelTrace = slog.Level(-8)
+	// LevelFatal for logs right before exiting, above ERROR
+	LevelFatal = slog.Level(12)
+
+	// levelAll lets every record reach the inner handlers, the levels are
+	// checked by customHandler.Enabled
+	levelAll = slog.Level(math.MinInt32)
+)
+
+// ParseLevel parses a level name, case-insensitively: trace, debug, info,
+// warn, error or fatal, optionally with an offset such as "info+2"
+func ParseLevel(s string) (slog.Level, error) {
+	name, offset := strings.ToUpper(strings.TrimSpace(s)), ""
+	if i := strings.IndexAny(name, "+-"); i > 0 {
+		name, offset = name[:i], name[i:]
+	}
+
+	var base slog.Level
+	switch name {
+	case "TRACE":
+		base = LevelTrace
+	case "FATAL":
+		base = LevelFatal
+	case "WARNING":
+		base = slog.LevelWarn
+	case "DEBUG", "INFO", "WARN", "ERROR":
+		if err := base.UnmarshalText([]byte(name)); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+
+	if offset == "" {
+		return base, nil
+	}
+
+	var n int
+	if _, err := fmt.Sscanf(offset, "%d", &n); err != nil || fmt.Sprintf("%+d", n) != offset {
+		return 0, fmt.Errorf("invalid log level offset %q", s)
+	}
+
+	return base + slog.Level(n), nil
+}
+
+// LevelName returns the name of the level, knowing about TRACE and FATAL
+func LevelName(l slog.Level) string {
+	switch {
+	case l < slog.LevelDebug && l >= LevelTrace:
+		return offsetName("TRACE", l-LevelTrace)
+	case l >= LevelFatal:
+		return offsetName("FATAL", l-LevelFatal)
+	}
+
+	return l.String()
+}
+
+func offsetName(name string, offset slog.Level) string {
+	if offset == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s%+d", name, offset)
+}
+
+// replaceLevelName renders TRACE and FATAL instead of DEBUG-4 and ERROR+4
+func replaceLevelName(groups []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.LevelKey || len(groups) > 0 {
+		return a
+	}
+
+	return levelName(a)
+}
+
+// withLevelName runs replaceAttr and then names the level like
+// replaceLevelName, also when replaceAttr renamed the level key
+func withLevelName(replaceAttr ReplaceAttrFunc) ReplaceAttrFunc {
+	if replaceAttr == nil {
+		return replaceLevelName
+	}
+
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key != slog.LevelKey || len(groups) > 0 {
+			return replaceAttr(groups, a)
+		}
+
+		return levelName(replaceAttr(groups, a))
+	}
+}
+
+func levelName(a slog.Attr) slog.Attr {
+	// Any boxes the other kinds, check the kind first on this hot path
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+
+	if l, ok := a.Value.Any().(slog.Level); ok {
+		a.Value = slog.StringValue(LevelName(l))
+	}
+
+	return a
+}
+
+// Levels holds a default level and per component overrides, all of them can
+// be changed at runtime. Components are named with Named, an override for
+// "database" also applies to "database.sql" unless it has its own.
+type Levels struct {
+	def       slog.LevelVar
+	mu        sync.Mutex
+	overrides atomic.Pointer[map[string]slog.Level]
+}
+
+// NewLevels to create levels with the given default
+func NewLevels(def slog.Level) *Levels {
+	l := &Levels{}
+	l.def.Set(def)
+	l.overrides.Store(&map[string]slog.Level{})
+	return l
+}
+
+// Level returns the level for a component, "" is the default level
+func (l *Levels) Level(component string) slog.Level {
+	overrides := *l.overrides.Load()
+
+	for component != "" {
+		if lvl, ok := overrides[component]; ok {
+			return lvl
+		}
+
+		i := strings.LastIndexByte(component, '.')
+		if i < 0 {
+			break
+		}
+		component = component[:i]
+	}
+
+	return l.def.Level()
+}
+
+// Set changes the level of a component, "" is the default level
+func (l *Levels) Set(component string, level slog.Level) {
+	if component == "" {
+		l.def.Set(level)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	overrides := l.copyOverrides()
+	overrides[component] = level
+	l.overrides.Store(&overrides)
+}
+
+// Reset removes the override of a component
+func (l *Levels) Reset(component string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	overrides := l.copyOverrides()
+	delete(overrides, component)
+	l.overrides.Store(&overrides)
+}
+
+// SetSpec replaces the overrides with the ones in spec, a comma separated
+// list such as "warn,database=debug,http=info". A bare level, or "*=level",
+// sets the default. Nothing changes when the spec is invalid.
+func (l *Levels) SetSpec(spec string) error {
+	def, overrides, err := parseSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if def != nil {
+		l.def.Set(*def)
+	}
+	l.overrides.Store(&overrides)
+
+	return nil
+}
+
+func parseSpec(spec string) (*slog.Level, map[string]slog.Level, error) {
+	var def *slog.Level
+	overrides := map[string]slog.Level{}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		component, name, found := strings.Cut(part, "=")
+		if !found {
+			component, name = "*", part
+		}
+
+		lvl, err := ParseLevel(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid level spec %q: %w", part, err)
+		}
+
+		component = strings.TrimSpace(component)
+		switch component {
+		case "":
+			return nil, nil, fmt.Errorf("invalid level spec %q: missing component", part)
+		case "*":
+			def = &lvl
+		default:
+			overrides[component] = lvl
+		}
+	}
+
+	return def, overrides, nil
+}
+
+// Spec returns the levels in the format accepted by SetSpec
+func (l *Levels) Spec() string {
+	overrides := *l.overrides.Load()
+
+	parts := make([]string, 0, len(overrides)+1)
+	for component, lvl := range overrides {
+		parts = append(parts, component+"="+strings.ToLower(LevelName(lvl)))
+	}
+	sort.Strings(parts)
+
+	return strings.Join(append([]string{strings.ToLower(LevelName(l.def.Level()))}, parts...), ",")
+}
+
+// Handler returns the spec on GET and replaces it with the request body on
+// PUT. Protect it like any other admin endpoint.
+func (l *Levels) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to read body: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+
+			if err := l.SetSpec(string(body)); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, l.Spec())
+	})
+}
+
+func (l *Levels) copyOverrides() map[string]slog.Level {
+	current := *l.overrides.Load()
+
+	overrides := make(map[string]slog.Level, len(current)+1)
+	for k, v := range current {
+		overrides[k] = v
+	}
+
+	return overrides
+}
@@ -0,0 +1,124 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]slog.Level{
+		"trace":   logger.LevelTrace,
+		"DEBUG":   slog.LevelDebug,
+		"Info":    slog.LevelInfo,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+		"fatal":   logger.LevelFatal,
+		"info+2":  slog.LevelInfo + 2,
+	} {
+		got, err := logger.ParseLevel(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+
+	for _, s := range []string{"", "verbose", "info+x", "info+02"} {
+		_, err := logger.ParseLevel(s)
+		assert.Error(t, err, s)
+	}
+
+	assert.Equal(t, "TRACE", logger.LevelName(logger.LevelTrace))
+	assert.Equal(t, "FATAL", logger.LevelName(logger.LevelFatal))
+	assert.Equal(t, "INFO", logger.LevelName(slog.LevelInfo))
+}
+
+func TestLevels(t *testing.T) {
+	t.Run("spec", func(t *testing.T) {
+		levels := logger.NewLevels(slog.LevelInfo)
+
+		assert.NoError(t, levels.SetSpec("warn, database=debug,http=trace"))
+		assert.Equal(t, slog.LevelWarn, levels.Level(""))
+		assert.Equal(t, slog.LevelDebug, levels.Level("database"))
+		assert.Equal(t, slog.LevelDebug, levels.Level("database.sql"))
+		assert.Equal(t, logger.LevelTrace, levels.Level("http"))
+		assert.Equal(t, slog.LevelWarn, levels.Level("server"))
+		assert.Equal(t, "warn,database=debug,http=trace", levels.Spec())
+
+		assert.Error(t, levels.SetSpec("error,database=loud"))
+		assert.Equal(t, "warn,database=debug,http=trace", levels.Spec())
+	})
+
+	t.Run("named loggers", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(logger.WithWriter(buf), logger.WithLevelSpec("warn,database=trace"))
+		db := logger.Named(log, "database")
+
+		log.Info("dropped")
+		db.Log(context.Background(), logger.LevelTrace, "query")
+		assert.NotContains(t, buf.String(), "dropped")
+		assert.Contains(t, buf.String(), "level=TRACE msg=query component=database")
+
+		levels, ok := logger.LevelsOf(log)
+		assert.True(t, ok)
+		levels.Set("database", slog.LevelError)
+		buf.Reset()
+		db.Warn("dropped")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("http handler", func(t *testing.T) {
+		levels := logger.NewLevels(slog.LevelInfo)
+		h := levels.Handler()
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader("error,http=debug")))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "error,http=debug\n", w.Body.String())
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader("nope")))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, slog.LevelError, levels.Level(""))
+	})
+
+	t.Run("invalid levels", func(t *testing.T) {
+		_, err := logger.TryNewLogger(logger.WithLevel("verbose"))
+		assert.Error(t, err)
+
+		_, err = logger.TryNewLogger(logger.WithLevelSpec("warn,database=loud"))
+		assert.Error(t, err)
+
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(logger.WithWriter(buf), logger.WithLevel("verbose"))
+		assert.Contains(t, buf.String(), `level=WARN msg="invalid log level, using the default"`)
+
+		levels, _ := logger.LevelsOf(log)
+		assert.Equal(t, slog.LevelWarn, levels.Level(""))
+
+		assert.Error(t, logger.Reconfigure(log, logger.WithLevel("verbose")))
+	})
+
+	t.Run("reconfigure the level changed at runtime", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(logger.WithWriter(buf), logger.WithLevel("warn"))
+
+		levels, _ := logger.LevelsOf(log)
+		levels.Set("", slog.LevelDebug)
+
+		// options not about levels keep the runtime level
+		assert.NoError(t, logger.Reconfigure(log, logger.WithJSON(true)))
+		assert.Equal(t, slog.LevelDebug, levels.Level(""))
+
+		assert.NoError(t, logger.Reconfigure(log, logger.WithLevel("warn")))
+		log.Info("dropped")
+		assert.Empty(t, buf.String())
+		assert.Equal(t, slog.LevelWarn, levels.Level(""))
+	})
+}
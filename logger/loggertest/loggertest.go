@@ -0,0 +1,320 @@
+// Package loggertest records logs in memory for asserting on them in tests,
+// and routes them to t.Log so they only show up when a test fails
+package loggertest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+// Entry is a recorded slog.Record
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Attrs of the record and of the logger, with groups joined by ".",
+	// e.g. "req.path"
+	Attrs map[string]slog.Value
+	// Record is a clone of the handled record, without the logger attrs
+	Record slog.Record
+}
+
+// Attr returns the value of the attr with key, resolved
+func (e Entry) Attr(key string) (any, bool) {
+	v, ok := e.Attrs[key]
+	if !ok {
+		return nil, false
+	}
+
+	return v.Any(), true
+}
+
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %q", logger.LevelName(e.Level), e.Message)
+
+	for k, v := range e.Attrs {
+		fmt.Fprintf(&b, " %s=%v", k, v)
+	}
+
+	return b.String()
+}
+
+// store is shared by a Recorder and the handlers derived from it
+type store struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Recorder is a slog.Handler keeping every record in memory, at any level
+type Recorder struct {
+	store  *store
+	attrs  []slog.Attr
+	groups []string
+}
+
+// NewRecorder returns an empty Recorder, use it with slog.New or as a
+// logger.Sink handler
+func NewRecorder() *Recorder {
+	return &Recorder{store: &store{}}
+}
+
+func (r *Recorder) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (r *Recorder) Handle(_ context.Context, rec slog.Record) error {
+	e := Entry{
+		Time:    rec.Time,
+		Level:   rec.Level,
+		Message: rec.Message,
+		Attrs:   map[string]slog.Value{},
+		Record:  rec.Clone(),
+	}
+
+	for _, a := range r.attrs {
+		flatten(e.Attrs, "", a)
+	}
+
+	prefix := ""
+	if len(r.groups) > 0 {
+		prefix = strings.Join(r.groups, ".") + "."
+	}
+
+	rec.Attrs(func(a slog.Attr) bool {
+		flatten(e.Attrs, prefix, a)
+		return true
+	})
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.entries = append(r.store.entries, e)
+
+	return nil
+}
+
+func (r *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return r
+	}
+
+	c := *r
+	c.attrs = r.attrs[:len(r.attrs):len(r.attrs)]
+	for _, a := range attrs {
+		// keep the groups the attrs were added in
+		for i := len(r.groups) - 1; i >= 0; i-- {
+			a = slog.Attr{Key: r.groups[i], Value: slog.GroupValue(a)}
+		}
+		c.attrs = append(c.attrs, a)
+	}
+
+	return &c
+}
+
+func (r *Recorder) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return r
+	}
+
+	c := *r
+	c.groups = append(r.groups[:len(r.groups):len(r.groups)], name)
+
+	return &c
+}
+
+// Entries returns the recorded entries, oldest first
+func (r *Recorder) Entries() []Entry {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return append([]Entry(nil), r.store.entries...)
+}
+
+// Find returns the entries logged at level with msg and attrs, given as
+// key-value pairs or slog.Attr like the arguments of slog.Logger.Info
+func (r *Recorder) Find(level slog.Level, msg string, attrs ...any) []Entry {
+	want := map[string]slog.Value{}
+	for _, a := range argsToAttrs(attrs) {
+		flatten(want, "", a)
+	}
+
+	var found []Entry
+	for _, e := range r.Entries() {
+		if e.Level == level && e.Message == msg && matches(e.Attrs, want) {
+			found = append(found, e)
+		}
+	}
+
+	return found
+}
+
+// Reset drops the recorded entries
+func (r *Recorder) Reset() {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.entries = nil
+}
+
+// AssertLogged fails the test unless an entry was logged at level with msg
+// and attrs, see Recorder.Find. It returns the first matching entry.
+func AssertLogged(t testing.TB, r *Recorder, level slog.Level, msg string, attrs ...any) Entry {
+	t.Helper()
+
+	found := r.Find(level, msg, attrs...)
+	if len(found) == 0 {
+		t.Errorf("no %s entry %q with %v, recorded:\n%s", logger.LevelName(level), msg, attrs, r.dump())
+		return Entry{}
+	}
+
+	return found[0]
+}
+
+// AssertNotLogged fails the test if an entry was logged at level with msg and attrs
+func AssertNotLogged(t testing.TB, r *Recorder, level slog.Level, msg string, attrs ...any) {
+	t.Helper()
+
+	if found := r.Find(level, msg, attrs...); len(found) > 0 {
+		t.Errorf("unexpected %s entry %q with %v, recorded:\n%s", logger.LevelName(level), msg, attrs, r.dump())
+	}
+}
+
+func (r *Recorder) dump() string {
+	var b strings.Builder
+	for _, e := range r.Entries() {
+		b.WriteString("\t" + e.String() + "\n")
+	}
+
+	return b.String()
+}
+
+// NewHandler returns a text handler writing to t.Log, which is only shown
+// when the test fails or with go test -v. Records logged after the test
+// completed are dropped. Level defaults to logger.LevelTrace.
+func NewHandler(t testing.TB, opts *slog.HandlerOptions) slog.Handler {
+	var options slog.HandlerOptions
+	if opts != nil {
+		options = *opts
+	}
+
+	if options.Level == nil {
+		options.Level = logger.LevelTrace
+	}
+
+	w := &tbWriter{t: t}
+	t.Cleanup(w.done)
+
+	return slog.NewTextHandler(w, &options)
+}
+
+// New returns a logger writing to t.Log and to the returned Recorder
+func New(t testing.TB) (*slog.Logger, *Recorder) {
+	r := NewRecorder()
+	return slog.New(logger.NewMultiHandler(NewHandler(t, nil), r)), r
+}
+
+// tbWriter writes to t.Log until the test is done
+type tbWriter struct {
+	mu       sync.Mutex
+	t        testing.TB
+	finished bool
+}
+
+func (w *tbWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.finished {
+		w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	}
+
+	return len(p), nil
+}
+
+func (w *tbWriter) done() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.finished = true
+}
+
+// flatten adds a to attrs, with the keys of nested groups joined by "."
+func flatten(attrs map[string]slog.Value, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() != slog.KindGroup {
+		if a.Key != "" {
+			attrs[prefix+a.Key] = a.Value
+		}
+		return
+	}
+
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+
+	for _, ga := range a.Value.Group() {
+		flatten(attrs, prefix, ga)
+	}
+}
+
+// argsToAttrs converts key-value pairs and slog.Attr like slog.Record.Add
+func argsToAttrs(args []any) []slog.Attr {
+	var r slog.Record
+	r.Add(args...)
+
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	return attrs
+}
+
+// matches reports whether got has all the wanted attrs. Errors match their
+// message, and numbers of any kind match by value.
+func matches(got, want map[string]slog.Value) bool {
+	for k, w := range want {
+		g, ok := got[k]
+		if !ok || !equal(g, w) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equal(got, want slog.Value) bool {
+	// Value.Equal panics on slices and maps
+	if got.Kind() == slog.KindAny && want.Kind() == slog.KindAny {
+		if reflect.DeepEqual(got.Any(), want.Any()) {
+			return true
+		}
+	} else if got.Equal(want) {
+		return true
+	}
+
+	if err, ok := got.Any().(error); ok && want.Kind() == slog.KindString {
+		return err.Error() == want.String()
+	}
+
+	switch got.Kind() {
+	case slog.KindInt64, slog.KindUint64, slog.KindFloat64:
+		switch want.Kind() {
+		case slog.KindInt64, slog.KindUint64, slog.KindFloat64:
+			return fmt.Sprint(got.Any()) == fmt.Sprint(want.Any())
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package loggertest_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+	"github.com/devshansharma/tools/logger/loggertest"
+)
+
+// fakeT captures the failures of the assertions under test
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, format)
+}
+
+func TestRecorder(t *testing.T) {
+	rec := loggertest.NewRecorder()
+	log := slog.New(rec).With("component", "billing")
+
+	log.WithGroup("req").Info("charged", "amount", 42, slog.Group("card", "last4", "4242"))
+	log.Error("charge failed", "error", errors.New("declined"))
+	log.Debug("details")
+
+	entries := rec.Entries()
+	assert.Len(t, entries, 3)
+
+	e := loggertest.AssertLogged(t, rec, slog.LevelInfo, "charged",
+		"component", "billing", "req.amount", 42, "req.card.last4", "4242")
+	amount, _ := e.Attr("req.amount")
+	assert.Equal(t, int64(42), amount)
+
+	loggertest.AssertLogged(t, rec, slog.LevelError, "charge failed", "error", "declined")
+	loggertest.AssertNotLogged(t, rec, slog.LevelWarn, "charged")
+
+	ft := &fakeT{TB: t}
+	loggertest.AssertLogged(ft, rec, slog.LevelInfo, "charged", "req.amount", 43)
+	loggertest.AssertNotLogged(ft, rec, slog.LevelDebug, "details")
+	assert.Len(t, ft.errors, 2)
+
+	rec.Reset()
+	assert.Empty(t, rec.Entries())
+}
+
+func TestRecorderUncomparableValues(t *testing.T) {
+	rec := loggertest.NewRecorder()
+	log := slog.New(rec)
+
+	log.Info("batch", "ids", []int{1, 2})
+	log.Info("labels", "tags", map[string]string{"team": "billing"})
+
+	loggertest.AssertLogged(t, rec, slog.LevelInfo, "batch", "ids", []int{1, 2})
+	loggertest.AssertLogged(t, rec, slog.LevelInfo, "labels", "tags", map[string]string{"team": "billing"})
+	loggertest.AssertNotLogged(t, rec, slog.LevelInfo, "batch", "ids", []int{3})
+	loggertest.AssertNotLogged(t, rec, slog.LevelInfo, "labels", "tags", map[string]string{"team": "orders"})
+	loggertest.AssertNotLogged(t, rec, slog.LevelInfo, "batch", "ids", "1,2")
+}
+
+func TestRecorderAsSink(t *testing.T) {
+	rec := loggertest.NewRecorder()
+	log := logger.NewLogger(logger.WithLevel("warn"), logger.WithSinks(logger.Sink{Handler: rec}))
+
+	log.Info("hidden")
+	log.Warn("shown", "n", 1)
+
+	assert.Len(t, rec.Entries(), 1)
+	loggertest.AssertLogged(t, rec, slog.LevelWarn, "shown", "n", 1)
+}
+
+// logT captures what is written with Log
+type logT struct {
+	testing.TB
+	lines []string
+}
+
+func (l *logT) Log(args ...any) {
+	l.lines = append(l.lines, args[0].(string))
+}
+
+func TestNew(t *testing.T) {
+	lt := &logT{TB: t}
+	log, rec := loggertest.New(lt)
+
+	log.Log(context.Background(), logger.LevelTrace, "very verbose", "k", "v")
+
+	assert.Len(t, rec.Entries(), 1)
+	assert.Len(t, lt.lines, 1)
+	assert.True(t, strings.Contains(lt.lines[0], `msg="very verbose" k=v`), lt.lines[0])
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// Format of the records written by a sink
+type Format int
+
+const (
+	FormatText Format = iota
+	FormatJSON
+	// FormatPretty for a PrettyHandler, or JSON when the writer is not a terminal
+	FormatPretty
+)
+
+// Sink is one destination of a logger created with WithSinks
+type Sink struct {
+	// Writer receives the records in Format
+	Writer io.Writer
+	// Handler is used as is instead of Writer, Format and ReplaceAttr
+	Handler slog.Handler
+	Format  Format
+	// Level filters the records let through by the logger level, nil keeps them all
+	Level slog.Leveler
+	// ReplaceAttr for this sink only, nil means the one from WithReplaceAttr
+	ReplaceAttr ReplaceAttrFunc
+}
+
+// WithSinks for sending every record to several sinks, each with its own
+// format, level and ReplaceAttr. It replaces WithWriter and WithJSON.
+// The logger level is checked first, so set it to the lowest sink level.
+func WithSinks(sinks ...Sink) func(*CustomLogger) {
+	return func(cl *CustomLogger) {
+		cl.sinks = sinks
+	}
+}
+
+func (l CustomLogger) newSinkHandler(sink Sink) slog.Handler {
+	if sink.Handler == nil {
+		replaceAttr := sink.ReplaceAttr
+		if replaceAttr == nil {
+			replaceAttr = l.replaceAttr
+		}
+
+		return l.newFormatHandler(sink.Writer, sink.Format, sink.Level, replaceAttr)
+	}
+
+	if sink.Level != nil {
+		return &levelHandler{Handler: sink.Handler, level: sink.Level}
+	}
+
+	return sink.Handler
+}
+
+// MultiHandler sends every record to all the handlers enabled for its level.
+// The handlers run concurrently and Handle returns once they are all done,
+// wrap a sink in NewAsyncHandler to not wait for it at all.
+// A failing handler does not stop the others, their errors are joined.
+type MultiHandler struct {
+	handlers []slog.Handler
+}
+
+// NewMultiHandler to fan records out to handlers
+func NewMultiHandler(handlers ...slog.Handler) *MultiHandler {
+	return &MultiHandler{handlers: handlers}
+}
+
+func (m *MultiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *MultiHandler) Handle(ctx context.Context, rec slog.Record) error {
+	enabled := make([]slog.Handler, 0, len(m.handlers))
+	for _, h := range m.handlers {
+		if h.Enabled(ctx, rec.Level) {
+			enabled = append(enabled, h)
+		}
+	}
+
+	if len(enabled) == 1 {
+		return enabled[0].Handle(ctx, rec)
+	}
+
+	// handlers run concurrently so a slow sink does not hold up the others,
+	// each one gets its own copy, as handlers may add attrs to it
+	errs := make([]error, len(enabled))
+	var wg sync.WaitGroup
+	for i, h := range enabled {
+		wg.Add(1)
+		go func(i int, h slog.Handler, rec slog.Record) {
+			defer wg.Done()
+			errs[i] = h.Handle(ctx, rec)
+		}(i, h, rec.Clone())
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (m *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(m.handlers))
+	for i, h := range m.handlers {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+
+	return &MultiHandler{handlers: handlers}
+}
+
+func (m *MultiHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(m.handlers))
+	for i, h := range m.handlers {
+		handlers[i] = h.WithGroup(name)
+	}
+
+	return &MultiHandler{handlers: handlers}
+}
+
+// levelHandler adds a minimum level to a handler
+type levelHandler struct {
+	slog.Handler
+	level slog.Leveler
+}
+
+func (l *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= l.level.Level() && l.Handler.Enabled(ctx, level)
+}
+
+func (l *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: l.Handler.WithAttrs(attrs), level: l.level}
+}
+
+func (l *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: l.Handler.WithGroup(name), level: l.level}
+}
@@ -0,0 +1,97 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestSinks(t *testing.T) {
+	console, file := &bytes.Buffer{}, &bytes.Buffer{}
+
+	log := logger.NewLogger(
+		logger.WithLevel("debug"),
+		logger.WithSinks(
+			logger.Sink{Writer: failingWriter{}, Format: logger.FormatJSON},
+			logger.Sink{Writer: console, Format: logger.FormatText},
+			logger.Sink{
+				Writer: file,
+				Format: logger.FormatJSON,
+				Level:  slog.LevelInfo,
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.MessageKey {
+						a.Key = "message"
+					}
+					return a
+				},
+			},
+		),
+	).With("k", "v")
+
+	log.Debug("debug only")
+	log.Info("everywhere")
+
+	assert.Contains(t, console.String(), "level=DEBUG msg=\"debug only\" k=v")
+	assert.Contains(t, console.String(), "level=INFO msg=everywhere k=v")
+	assert.NotContains(t, file.String(), "debug only")
+	assert.Contains(t, file.String(), `"level":"INFO","message":"everywhere","k":"v"`)
+}
+
+func TestMultiHandlerErrors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := logger.NewMultiHandler(
+		slog.NewJSONHandler(failingWriter{}, nil),
+		slog.NewJSONHandler(buf, nil),
+	)
+
+	err := slog.New(h).Handler().Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "hello", 0))
+	assert.ErrorContains(t, err, "disk full")
+	assert.Contains(t, buf.String(), `"msg":"hello"`)
+}
+
+type blockingHandler struct {
+	slog.Handler
+	release chan struct{}
+}
+
+func (b blockingHandler) Handle(ctx context.Context, rec slog.Record) error {
+	<-b.release
+	return b.Handler.Handle(ctx, rec)
+}
+
+func TestMultiHandlerConcurrent(t *testing.T) {
+	slow, fast := &bytes.Buffer{}, &syncBuffer{}
+	release := make(chan struct{})
+	h := logger.NewMultiHandler(
+		blockingHandler{Handler: slog.NewJSONHandler(slow, nil), release: release},
+		slog.NewJSONHandler(fast, nil),
+	)
+
+	done := make(chan error)
+	go func() {
+		done <- h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "hello", 0))
+	}()
+
+	// the fast sink is written while the slow one is still blocked
+	assert.Eventually(t, func() bool {
+		return strings.Contains(fast.String(), `"msg":"hello"`)
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Contains(t, slow.String(), `"msg":"hello"`)
+}
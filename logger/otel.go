@@ -0,0 +1,344 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+const otelScope = "github.com/devshansharma/tools/logger"
+
+// OTLPOption to configure OTLPHandler
+type OTLPOption func(o *otlpConfig)
+
+type otlpConfig struct {
+	resource []slog.Attr
+	trace    func(ctx context.Context) (TraceContext, bool)
+	httpOpts []HTTPOption
+}
+
+// WithServiceName for the service.name resource attribute, default is
+// unknown_service:<executable>
+func WithServiceName(name string) OTLPOption {
+	return func(o *otlpConfig) {
+		o.resource = append(o.resource, slog.String("service.name", name))
+	}
+}
+
+// WithServiceVersion for the service.version resource attribute
+func WithServiceVersion(version string) OTLPOption {
+	return func(o *otlpConfig) {
+		o.resource = append(o.resource, slog.String("service.version", version))
+	}
+}
+
+// WithResourceAttrs for adding resource attributes, e.g.
+// slog.String("deployment.environment", "production")
+func WithResourceAttrs(attrs ...slog.Attr) OTLPOption {
+	return func(o *otlpConfig) {
+		o.resource = append(o.resource, attrs...)
+	}
+}
+
+// WithTraceExtractor for reading the trace context of a record from its
+// context, default is TraceFromContext. With the OpenTelemetry SDK, adapt
+// trace.SpanContextFromContext.
+func WithTraceExtractor(f func(ctx context.Context) (TraceContext, bool)) OTLPOption {
+	return func(o *otlpConfig) {
+		o.trace = f
+	}
+}
+
+// WithHTTPOptions for batching, retries, headers and spooling of the
+// export, see HTTPHandler
+func WithHTTPOptions(opts ...HTTPOption) OTLPOption {
+	return func(o *otlpConfig) {
+		o.httpOpts = append(o.httpOpts, opts...)
+	}
+}
+
+// OTLPHandler maps records to the OpenTelemetry log data model and exports
+// them in batches over OTLP/HTTP with the JSON encoding. Attrs in groups
+// are flattened with ".", errors add the exception.* attributes and the
+// trace context of the record context sets the trace and span ids.
+type OTLPHandler struct {
+	opts   slog.HandlerOptions
+	config *otlpConfig
+	attrs  []flatAttr
+	groups []string
+	s      *shipper
+}
+
+// NewOTLPHandler starts exporting to endpoint, e.g.
+// "http://localhost:4318/v1/logs", the path defaults to /v1/logs. opts are
+// used like by slog.NewJSONHandler.
+func NewOTLPHandler(endpoint string, opts *slog.HandlerOptions, oopts ...OTLPOption) (*OTLPHandler, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/logs"
+	}
+
+	o := &otlpConfig{
+		trace: TraceFromContext,
+	}
+
+	for _, opt := range oopts {
+		opt(o)
+	}
+
+	if !hasKey(o.resource, "service.name") {
+		o.resource = append(o.resource, slog.String("service.name", "unknown_service:"+filepath.Base(os.Args[0])))
+	}
+
+	resource, err := json.Marshal(otlpKeyValues(o.resource))
+	if err != nil {
+		return nil, err
+	}
+
+	batchOpt := func(s *shipper) {
+		s.contentType = "application/json"
+		s.encode = func(records [][]byte) []byte {
+			return encodeOTLPBatch(resource, records)
+		}
+	}
+
+	s, err := newShipper(u.String(), append(o.httpOpts[:len(o.httpOpts):len(o.httpOpts)], batchOpt)...)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &OTLPHandler{config: o, s: s}
+	if opts != nil {
+		h.opts = *opts
+	}
+
+	return h, nil
+}
+
+func (h *OTLPHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= handlerLevel(&h.opts).Level()
+}
+
+func (h *OTLPHandler) Handle(ctx context.Context, rec slog.Record) error {
+	var recAttrs []slog.Attr
+	rec.Attrs(func(a slog.Attr) bool {
+		recAttrs = append(recAttrs, a)
+		return true
+	})
+
+	attrs := flattenAttrs(h.attrs[:len(h.attrs):len(h.attrs)], h.groups, recAttrs, ".", h.opts.ReplaceAttr)
+
+	lr := otlpLogRecord{
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       severityNumber(rec.Level),
+		SeverityText:         LevelName(rec.Level),
+		Body:                 otlpValue(slog.StringValue(replaceMessage(h.opts.ReplaceAttr, rec.Message))),
+	}
+
+	if !rec.Time.IsZero() {
+		lr.TimeUnixNano = strconv.FormatInt(rec.Time.UnixNano(), 10)
+	}
+
+	if h.opts.AddSource && rec.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{rec.PC}).Next()
+		attrs = append(attrs,
+			flatAttr{key: "code.filepath", value: slog.StringValue(f.File)},
+			flatAttr{key: "code.lineno", value: slog.IntValue(f.Line)},
+			flatAttr{key: "code.function", value: slog.StringValue(f.Function)},
+		)
+	}
+
+	exception := false
+	for _, a := range attrs {
+		lr.Attributes = append(lr.Attributes, otlpKeyValue{Key: a.key, Value: otlpValue(a.value)})
+
+		if a.value.Kind() != slog.KindAny || exception {
+			continue
+		}
+
+		if err, ok := a.value.Any().(error); ok {
+			exception = true
+			lr.Attributes = append(lr.Attributes, exceptionAttrs(err)...)
+		}
+	}
+
+	if t, ok := h.config.trace(ctx); ok {
+		lr.TraceID = hex.EncodeToString(t.TraceID[:])
+		lr.SpanID = hex.EncodeToString(t.SpanID[:])
+		lr.Flags = uint32(t.Flags)
+	}
+
+	data, err := json.Marshal(lr)
+	if err != nil {
+		return err
+	}
+
+	return h.s.add(ctx, data)
+}
+
+func (h *OTLPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	c := *h
+	c.attrs = flattenAttrs(c.attrs[:len(c.attrs):len(c.attrs)], c.groups, attrs, ".", h.opts.ReplaceAttr)
+
+	return &c
+}
+
+func (h *OTLPHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	c := *h
+	c.groups = append(c.groups[:len(c.groups):len(c.groups)], name)
+
+	return &c
+}
+
+// Dropped returns the number of records dropped, see HTTPHandler.Dropped
+func (h *OTLPHandler) Dropped() uint64 {
+	return h.s.dropped.Load()
+}
+
+// Flush exports the pending records
+func (h *OTLPHandler) Flush(ctx context.Context) error {
+	return h.s.flush(ctx)
+}
+
+// Close stops the background goroutine and exports the pending records
+// until ctx is done, see HTTPHandler.Close
+func (h *OTLPHandler) Close(ctx context.Context) error {
+	return h.s.close(ctx)
+}
+
+// severityNumber maps a level to the OpenTelemetry severity number, the
+// ranges of four numbers per severity match the slog levels, from TRACE=1
+// to FATAL=21
+func severityNumber(l slog.Level) int {
+	return min(max(int(l)+9, 1), 24)
+}
+
+func exceptionAttrs(err error) []otlpKeyValue {
+	kvs := []otlpKeyValue{
+		{Key: "exception.type", Value: otlpValue(slog.StringValue(typeName(err)))},
+		{Key: "exception.message", Value: otlpValue(slog.StringValue(err.Error()))},
+	}
+
+	if frames := marshalStack(err); len(frames) > 0 {
+		var b bytes.Buffer
+		for _, f := range frames {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Func, f.Source, f.Line)
+		}
+		kvs = append(kvs, otlpKeyValue{Key: "exception.stacktrace", Value: otlpValue(slog.StringValue(b.String()))})
+	}
+
+	return kvs
+}
+
+// encodeOTLPBatch wraps log records in an ExportLogsServiceRequest
+func encodeOTLPBatch(resource []byte, records [][]byte) []byte {
+	var b bytes.Buffer
+
+	b.WriteString(`{"resourceLogs":[{"resource":{"attributes":`)
+	b.Write(resource)
+	b.WriteString(`},"scopeLogs":[{"scope":{"name":"` + otelScope + `"},"logRecords":[`)
+	b.Write(bytes.Join(records, []byte{','}))
+	b.WriteString(`]}]}]}`)
+
+	return b.Bytes()
+}
+
+// OTLP/JSON types, see opentelemetry-proto. 64 bit integers are strings and
+// ids are hex encoded.
+type (
+	otlpLogRecord struct {
+		TimeUnixNano         string         `json:"timeUnixNano,omitempty"`
+		ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+		SeverityNumber       int            `json:"severityNumber"`
+		SeverityText         string         `json:"severityText"`
+		Body                 otlpAnyValue   `json:"body"`
+		Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+		Flags                uint32         `json:"flags,omitempty"`
+		TraceID              string         `json:"traceId,omitempty"`
+		SpanID               string         `json:"spanId,omitempty"`
+	}
+
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+
+	otlpAnyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+		BytesValue  []byte   `json:"bytesValue,omitempty"`
+	}
+)
+
+func otlpKeyValues(attrs []slog.Attr) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range flattenAttrs(nil, nil, attrs, ".", nil) {
+		kvs = append(kvs, otlpKeyValue{Key: a.key, Value: otlpValue(a.value)})
+	}
+
+	return kvs
+}
+
+func otlpValue(v slog.Value) otlpAnyValue {
+	str := func(s string) otlpAnyValue { return otlpAnyValue{StringValue: &s} }
+	integer := func(s string) otlpAnyValue { return otlpAnyValue{IntValue: &s} }
+
+	switch v.Kind() {
+	case slog.KindString:
+		return str(v.String())
+	case slog.KindBool:
+		b := v.Bool()
+		return otlpAnyValue{BoolValue: &b}
+	case slog.KindInt64:
+		return integer(strconv.FormatInt(v.Int64(), 10))
+	case slog.KindUint64:
+		return integer(strconv.FormatUint(v.Uint64(), 10))
+	case slog.KindFloat64:
+		f := v.Float64()
+		return otlpAnyValue{DoubleValue: &f}
+	case slog.KindDuration:
+		return integer(strconv.FormatInt(int64(v.Duration()), 10))
+	case slog.KindTime:
+		return str(v.Time().Format(time.RFC3339Nano))
+	}
+
+	switch a := v.Any().(type) {
+	case error:
+		return str(a.Error())
+	case slog.Level:
+		return str(LevelName(a))
+	case []byte:
+		return otlpAnyValue{BytesValue: a}
+	case fmt.Stringer:
+		return str(a.String())
+	}
+
+	if data, err := json.Marshal(v.Any()); err == nil {
+		return str(string(data))
+	}
+
+	return str(fmt.Sprintf("%+v", v.Any()))
+}
@@ -0,0 +1,230 @@
+package logger_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+// otlpRequest is the part of ExportLogsServiceRequest the tests look at
+type otlpRequest struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []otlpKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			LogRecords []otlpRecord `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+type otlpRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           map[string]any `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes"`
+	TraceID        string         `json:"traceId"`
+	SpanID         string         `json:"spanId"`
+	Flags          int            `json:"flags"`
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func attrValue(kvs []otlpKeyValue, key string) any {
+	for _, kv := range kvs {
+		if kv.Key == key {
+			for _, v := range kv.Value {
+				return v
+			}
+		}
+	}
+
+	return nil
+}
+
+// newOTLPCollector is a stand-in OTLP/HTTP logs endpoint
+func newOTLPCollector(t *testing.T) (func() []otlpRequest, *httptest.Server) {
+	var (
+		mu       sync.Mutex
+		requests []otlpRequest
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests = append(requests, req)
+	}))
+	t.Cleanup(srv.Close)
+
+	return func() []otlpRequest {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]otlpRequest(nil), requests...)
+	}, srv
+}
+
+func TestOTLPHandler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("exports the log data model", func(t *testing.T) {
+		received, srv := newOTLPCollector(t)
+
+		h, err := logger.NewOTLPHandler(srv.URL, &slog.HandlerOptions{Level: slog.LevelDebug, AddSource: true},
+			logger.WithServiceName("orders"),
+			logger.WithServiceVersion("1.2.3"),
+			logger.WithResourceAttrs(slog.String("deployment.environment", "test")),
+			logger.WithHTTPOptions(logger.WithFlushInterval(time.Hour)),
+		)
+		assert.NoError(t, err)
+
+		trace, err := logger.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		assert.NoError(t, err)
+
+		log := slog.New(h).With("component", "api")
+		log.WithGroup("req").InfoContext(logger.ContextWithTrace(ctx, trace), "created", "id", 42, "ok", true)
+		log.Error("failed", "err", errors.New("boom"), "ratio", 0.5)
+		log.Log(ctx, logger.LevelTrace, "ignored below debug")
+
+		assert.NoError(t, h.Close(ctx))
+
+		requests := received()
+		if !assert.Len(t, requests, 1) {
+			return
+		}
+
+		rl := requests[0].ResourceLogs[0]
+		assert.Equal(t, "orders", attrValue(rl.Resource.Attributes, "service.name"))
+		assert.Equal(t, "1.2.3", attrValue(rl.Resource.Attributes, "service.version"))
+		assert.Equal(t, "test", attrValue(rl.Resource.Attributes, "deployment.environment"))
+		assert.Equal(t, "github.com/devshansharma/tools/logger", rl.ScopeLogs[0].Scope.Name)
+
+		records := rl.ScopeLogs[0].LogRecords
+		if !assert.Len(t, records, 2) {
+			return
+		}
+
+		info := records[0]
+		assert.Equal(t, 9, info.SeverityNumber)
+		assert.Equal(t, "INFO", info.SeverityText)
+		assert.Equal(t, "created", info.Body["stringValue"])
+		assert.NotEmpty(t, info.TimeUnixNano)
+		assert.Equal(t, "api", attrValue(info.Attributes, "component"))
+		assert.Equal(t, "42", attrValue(info.Attributes, "req.id"))
+		assert.Equal(t, true, attrValue(info.Attributes, "req.ok"))
+		assert.Contains(t, attrValue(info.Attributes, "code.function"), "TestOTLPHandler")
+		assert.Contains(t, attrValue(info.Attributes, "code.filepath"), "otel_test.go")
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", info.TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", info.SpanID)
+		assert.Equal(t, 1, info.Flags)
+
+		failed := records[1]
+		assert.Equal(t, 17, failed.SeverityNumber)
+		assert.Equal(t, "ERROR", failed.SeverityText)
+		assert.Equal(t, 0.5, attrValue(failed.Attributes, "ratio"))
+		assert.Equal(t, "boom", attrValue(failed.Attributes, "exception.message"))
+		assert.Equal(t, "*errors.errorString", attrValue(failed.Attributes, "exception.type"))
+		assert.Empty(t, failed.TraceID)
+	})
+
+	t.Run("replace attr applies to the body", func(t *testing.T) {
+		received, srv := newOTLPCollector(t)
+
+		h, err := logger.NewOTLPHandler(srv.URL, &slog.HandlerOptions{ReplaceAttr: logger.NewRedactor()})
+		assert.NoError(t, err)
+
+		slog.New(h).Warn("reset sent to jane@example.com", "to", "jane@example.com")
+		assert.NoError(t, h.Close(ctx))
+
+		requests := received()
+		if !assert.Len(t, requests, 1) {
+			return
+		}
+
+		rec := requests[0].ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+		assert.Equal(t, "reset sent to [REDACTED]", rec.Body["stringValue"])
+		assert.Equal(t, "[REDACTED]", attrValue(rec.Attributes, "to"))
+	})
+
+	t.Run("default service name and trace extractor", func(t *testing.T) {
+		received, srv := newOTLPCollector(t)
+
+		h, err := logger.NewOTLPHandler(srv.URL+"/", nil,
+			logger.WithTraceExtractor(func(ctx context.Context) (logger.TraceContext, bool) {
+				return logger.TraceContext{TraceID: [16]byte{1}, SpanID: [8]byte{2}}, true
+			}),
+		)
+		assert.NoError(t, err)
+
+		slog.New(h).Warn("slow")
+		assert.NoError(t, h.Close(ctx))
+
+		requests := received()
+		if !assert.Len(t, requests, 1) {
+			return
+		}
+
+		rl := requests[0].ResourceLogs[0]
+		assert.Contains(t, attrValue(rl.Resource.Attributes, "service.name"), "unknown_service:")
+
+		rec := rl.ScopeLogs[0].LogRecords[0]
+		assert.Equal(t, 13, rec.SeverityNumber)
+		assert.Equal(t, "01000000000000000000000000000000", rec.TraceID)
+		assert.Equal(t, "0200000000000000", rec.SpanID)
+	})
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tc, err := logger.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoError(t, err)
+	assert.True(t, tc.IsValid())
+	assert.Equal(t, byte(1), tc.Flags)
+
+	ctx := logger.ContextWithTrace(context.Background(), tc)
+	got, ok := logger.TraceFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, tc, got)
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err := logger.ParseTraceparent(s)
+		assert.Error(t, err, s)
+	}
+
+	_, ok = logger.TraceFromContext(context.Background())
+	assert.False(t, ok)
+}
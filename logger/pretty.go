@@ -0,0 +1,445 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	colorReset   = "\033[0m"
+	colorDim     = "\033[2m"
+	colorRed     = "\033[31m"
+	colorCyan    = "\033[36m"
+	badgeTrace   = "\033[30;47m"
+	badgeDebug   = "\033[30;44m"
+	badgeInfo    = "\033[30;42m"
+	badgeWarn    = "\033[30;43m"
+	badgeError   = "\033[97;41m"
+	badgeFatal   = "\033[97;45m"
+	prettyMsgLen = 40
+)
+
+// PrettyOption to configure NewPrettyHandler
+type PrettyOption func(p *prettyConfig)
+
+type prettyConfig struct {
+	colors bool
+	start  time.Time
+}
+
+// WithColors for forcing colors on or off, by default they are used when
+// writing to a terminal and NO_COLOR is not set
+func WithColors(b bool) PrettyOption {
+	return func(p *prettyConfig) {
+		p.colors = b
+	}
+}
+
+// WithStart for the time relative timestamps are counted from, default is
+// the creation of the handler
+func WithStart(t time.Time) PrettyOption {
+	return func(p *prettyConfig) {
+		p.start = t
+	}
+}
+
+// WithPretty for colored, aligned output meant for a developer console.
+// When the writer is not a terminal, e.g. piped to a file or a log shipper,
+// records are written as JSON instead.
+func WithPretty(b bool) func(*CustomLogger) {
+	return func(cl *CustomLogger) {
+		cl.pretty = b
+	}
+}
+
+// isTerminal reports whether w is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// PrettyHandler writes one line per record with a relative timestamp, a level
+// badge, the message padded for alignment and the attrs as key=value, followed
+// by the errors and stacks of the record, one frame per line
+type PrettyHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	opts   slog.HandlerOptions
+	config prettyConfig
+	attrs  []flatAttr
+	groups []string
+}
+
+// NewPrettyHandler creates a PrettyHandler, opts are used like by
+// slog.NewTextHandler except that ReplaceAttr is not called for the time,
+// level and source. Errors, errors rendered by NewErrorTrace and stacks are
+// written as blocks from the value returned by ReplaceAttr, the messages of
+// their causes and their frames also go through ReplaceAttr, e.g. to be
+// redacted.
+func NewPrettyHandler(w io.Writer, opts *slog.HandlerOptions, popts ...PrettyOption) *PrettyHandler {
+	p := &PrettyHandler{
+		w:  w,
+		mu: &sync.Mutex{},
+		config: prettyConfig{
+			colors: isTerminal(w) && os.Getenv("NO_COLOR") == "",
+			start:  time.Now(),
+		},
+	}
+
+	if opts != nil {
+		p.opts = *opts
+	}
+
+	for _, opt := range popts {
+		opt(&p.config)
+	}
+
+	return p
+}
+
+func (p *PrettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= handlerLevel(&p.opts).Level()
+}
+
+func (p *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return p
+	}
+
+	c := *p
+	c.attrs = p.flatten(c.attrs[:len(c.attrs):len(c.attrs)], c.groups, attrs)
+
+	return &c
+}
+
+func (p *PrettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return p
+	}
+
+	c := *p
+	c.groups = append(c.groups[:len(c.groups):len(c.groups)], name)
+
+	return &c
+}
+
+func (p *PrettyHandler) Handle(_ context.Context, rec slog.Record) error {
+	var recAttrs []slog.Attr
+	rec.Attrs(func(a slog.Attr) bool {
+		recAttrs = append(recAttrs, a)
+		return true
+	})
+
+	attrs := p.flatten(p.attrs[:len(p.attrs):len(p.attrs)], p.groups, recAttrs)
+	msg := p.message(rec.Message)
+
+	buf := &bytes.Buffer{}
+
+	elapsed := rec.Time.Sub(p.config.start)
+	if rec.Time.IsZero() {
+		elapsed = 0
+	}
+	p.colorize(buf, colorDim, fmt.Sprintf("%9.3fs", elapsed.Seconds()))
+	buf.WriteByte(' ')
+
+	p.colorize(buf, badge(rec.Level), fmt.Sprintf(" %-5s ", LevelName(rec.Level)))
+	buf.WriteByte(' ')
+
+	buf.WriteString(msg)
+
+	var blocks []flatAttr
+	var inline []flatAttr
+	for _, a := range attrs {
+		if isBlock(a.value) {
+			blocks = append(blocks, a)
+			continue
+		}
+
+		inline = append(inline, a)
+	}
+
+	if len(inline) > 0 {
+		if n := utf8.RuneCountInString(msg); n < prettyMsgLen {
+			buf.WriteString(strings.Repeat(" ", prettyMsgLen-n))
+		}
+
+		for _, a := range inline {
+			buf.WriteByte(' ')
+			p.colorize(buf, colorCyan, a.key+"=")
+			buf.WriteString(formatValue(a.value))
+		}
+	}
+
+	if p.opts.AddSource && rec.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{rec.PC}).Next()
+		buf.WriteByte(' ')
+		p.colorize(buf, colorDim, fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line))
+	}
+
+	buf.WriteByte('\n')
+
+	for _, a := range blocks {
+		p.writeBlock(buf, a)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err := p.w.Write(buf.Bytes())
+
+	return err
+}
+
+// message returns the message after ReplaceAttr, e.g. redacted
+func (p *PrettyHandler) message(msg string) string {
+	if p.opts.ReplaceAttr == nil {
+		return msg
+	}
+
+	a := p.opts.ReplaceAttr(nil, slog.String(slog.MessageKey, msg))
+	if a.Equal(slog.Attr{}) {
+		return ""
+	}
+
+	return a.Value.Resolve().String()
+}
+
+// flatten is flattenAttrs keeping the blocks returned by ReplaceAttr, such
+// as the groups of NewErrorTrace, instead of flattening them
+func (p *PrettyHandler) flatten(dst []flatAttr, groups []string, attrs []slog.Attr) []flatAttr {
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+
+		if a.Value.Kind() == slog.KindGroup {
+			if a.Key == "" {
+				dst = p.flatten(dst, groups, a.Value.Group())
+				continue
+			}
+
+			dst = p.flatten(dst, append(groups[:len(groups):len(groups)], a.Key), a.Value.Group())
+			continue
+		}
+
+		if p.opts.ReplaceAttr != nil {
+			a = p.opts.ReplaceAttr(groups, a)
+			a.Value = a.Value.Resolve()
+		}
+
+		if a.Key != "" && isBlock(a.Value) {
+			dst = append(dst, flatAttr{key: strings.Join(append(groups[:len(groups):len(groups)], a.Key), "."), value: a.Value})
+			continue
+		}
+
+		dst = flattenAttrs(dst, groups, []slog.Attr{a}, ".", nil)
+	}
+
+	return dst
+}
+
+// isBlock reports whether the value is written on its own lines
+func isBlock(v slog.Value) bool {
+	if v.Kind() == slog.KindGroup {
+		return isErrorGroup(v)
+	}
+
+	if v.Kind() != slog.KindAny {
+		return false
+	}
+
+	switch v.Any().(type) {
+	case error, []stackFrame:
+		return true
+	}
+
+	return false
+}
+
+// isErrorGroup reports whether the group is an error rendered by
+// NewErrorTrace, starting with its msg and type
+func isErrorGroup(v slog.Value) bool {
+	attrs := v.Group()
+
+	return len(attrs) >= 2 && attrs[0].Key == "msg" && attrs[1].Key == "type" &&
+		attrs[0].Value.Kind() == slog.KindString && attrs[1].Value.Kind() == slog.KindString
+}
+
+// writeBlock writes an error with its causes and trace, or a captured stack
+func (p *PrettyHandler) writeBlock(buf *bytes.Buffer, a flatAttr) {
+	buf.WriteString("    ")
+
+	if a.value.Kind() == slog.KindGroup {
+		attrs := a.value.Group()
+
+		p.colorize(buf, colorCyan, a.key+": ")
+		p.colorize(buf, colorRed, attrs[0].Value.String())
+		buf.WriteByte('\n')
+
+		p.writeErrorGroup(buf, a.key, attrs, "      ")
+		return
+	}
+
+	switch v := a.value.Any().(type) {
+	case error:
+		p.colorize(buf, colorCyan, a.key+": ")
+		p.colorize(buf, colorRed, p.replaceString(a.key, v.Error()))
+		buf.WriteByte('\n')
+
+		p.writeCauses(buf, a.key, v, "      ")
+		p.writeFrames(buf, a.key, marshalStack(v), "      ")
+	case []stackFrame:
+		p.colorize(buf, colorCyan, a.key+":")
+		buf.WriteByte('\n')
+
+		p.writeFrames(buf, a.key, v, "      ")
+	}
+}
+
+// writeCauses writes the type of err and its causes, skipping wrappers that
+// don't change the message
+func (p *PrettyHandler) writeCauses(buf *bytes.Buffer, key string, err error, indent string) {
+	msg := err.Error()
+	for next := errors.Unwrap(err); next != nil && next.Error() == msg; next = errors.Unwrap(next) {
+		err = next
+	}
+
+	p.colorize(buf, colorDim, fmt.Sprintf("%s%T\n", indent, err))
+
+	for _, cause := range unwrapAll(err) {
+		buf.WriteString(indent + "caused by: ")
+		p.colorize(buf, colorRed, p.replaceString(key, cause.Error()))
+		buf.WriteByte('\n')
+
+		p.writeCauses(buf, key, cause, indent+"  ")
+	}
+}
+
+// writeErrorGroup writes the type, attrs, trace and causes of an error
+// rendered by NewErrorTrace, they already went through ReplaceAttr
+func (p *PrettyHandler) writeErrorGroup(buf *bytes.Buffer, key string, attrs []slog.Attr, indent string) {
+	p.colorize(buf, colorDim, indent+attrs[1].Value.String()+"\n")
+
+	var causes []slog.Attr
+	for _, a := range attrs[2:] {
+		switch a.Key {
+		case "attrs":
+			for _, f := range flattenAttrs(nil, nil, a.Value.Group(), ".", nil) {
+				buf.WriteString(indent)
+				p.colorize(buf, colorCyan, f.key+"=")
+				buf.WriteString(formatValue(f.value) + "\n")
+			}
+		case "trace":
+			if frames, ok := a.Value.Any().([]stackFrame); ok {
+				p.writeFrames(buf, key, frames, indent)
+			}
+		case "cause":
+			causes = append(causes, a)
+		case "causes":
+			causes = append(causes, a.Value.Group()...)
+		case "truncated":
+			p.colorize(buf, colorDim, indent+"...\n")
+		}
+	}
+
+	for _, cause := range causes {
+		if cause.Value.Kind() != slog.KindGroup || !isErrorGroup(cause.Value) {
+			continue
+		}
+
+		attrs := cause.Value.Group()
+		buf.WriteString(indent + "caused by: ")
+		p.colorize(buf, colorRed, attrs[0].Value.String())
+		buf.WriteByte('\n')
+
+		p.writeErrorGroup(buf, key, attrs, indent+"  ")
+	}
+}
+
+func (p *PrettyHandler) writeFrames(buf *bytes.Buffer, key string, frames []stackFrame, indent string) {
+	for _, f := range frames {
+		buf.WriteString(indent + "at " + p.replaceString(key, f.Func) + " ")
+		p.colorize(buf, colorDim, fmt.Sprintf("%s:%d", p.replaceString(key, f.Source), f.Line))
+		buf.WriteByte('\n')
+	}
+}
+
+// replaceString returns s after ReplaceAttr, as the value of key, for the
+// strings of blocks, e.g. redacted
+func (p *PrettyHandler) replaceString(key, s string) string {
+	if p.opts.ReplaceAttr == nil {
+		return s
+	}
+
+	a := p.opts.ReplaceAttr(nil, slog.String(key, s))
+	if a.Value.Kind() != slog.KindString {
+		return formatValue(a.Value.Resolve())
+	}
+
+	return a.Value.String()
+}
+
+func (p *PrettyHandler) colorize(buf *bytes.Buffer, color, s string) {
+	if !p.config.colors {
+		buf.WriteString(s)
+		return
+	}
+
+	buf.WriteString(color)
+	buf.WriteString(s)
+	buf.WriteString(colorReset)
+}
+
+func badge(l slog.Level) string {
+	switch {
+	case l >= LevelFatal:
+		return badgeFatal
+	case l >= slog.LevelError:
+		return badgeError
+	case l >= slog.LevelWarn:
+		return badgeWarn
+	case l >= slog.LevelInfo:
+		return badgeInfo
+	case l >= slog.LevelDebug:
+		return badgeDebug
+	}
+
+	return badgeTrace
+}
+
+// formatValue renders v like the text handler, quoting strings when needed
+func formatValue(v slog.Value) string {
+	var s string
+
+	switch v.Kind() {
+	case slog.KindString:
+		s = v.String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if l, ok := v.Any().(slog.Level); ok {
+			return LevelName(l)
+		}
+		s = fmt.Sprintf("%+v", v.Any())
+	default:
+		return v.String()
+	}
+
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") || !utf8.ValidString(s) {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
@@ -0,0 +1,138 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdobak/go-xerrors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+func TestPrettyHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	start := time.Now().Add(-1500 * time.Millisecond)
+	log := slog.New(logger.NewPrettyHandler(buf, &slog.HandlerOptions{Level: logger.LevelTrace}, logger.WithStart(start)))
+
+	t.Run("aligned line", func(t *testing.T) {
+		defer buf.Reset()
+
+		log.With("component", "http").WithGroup("req").Info("started", "path", "/users", "query", "a b")
+
+		line := buf.String()
+		assert.Regexp(t, `^\s+1\.5\d\ds  INFO   started {34}component=http req\.path=/users req\.query="a b"\n$`, line)
+	})
+
+	t.Run("level badges", func(t *testing.T) {
+		defer buf.Reset()
+
+		log.Log(context.Background(), logger.LevelTrace, "t")
+		log.Log(context.Background(), logger.LevelFatal, "f")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Contains(t, lines[0], " TRACE  t")
+		assert.Contains(t, lines[1], " FATAL  f")
+	})
+
+	t.Run("error with causes and trace", func(t *testing.T) {
+		defer buf.Reset()
+
+		err := fmt.Errorf("loading config: %w", xerrors.New("disk failure"))
+		log.Error("failed", "error", err)
+
+		lines := strings.Split(buf.String(), "\n")
+		assert.Contains(t, lines[0], "ERROR  failed")
+		assert.Equal(t, "    error: loading config: disk failure", lines[1])
+		assert.Equal(t, "      *fmt.wrapError", lines[2])
+		assert.Equal(t, "      caused by: disk failure", lines[3])
+		assert.Equal(t, "        *xerrors.messageError", lines[4])
+		assert.Contains(t, lines[5], "      at logger_test.TestPrettyHandler")
+	})
+
+	t.Run("colors", func(t *testing.T) {
+		colored := &bytes.Buffer{}
+		slog.New(logger.NewPrettyHandler(colored, nil, logger.WithColors(true))).Warn("slow")
+
+		assert.Contains(t, colored.String(), "\033[30;43m WARN  \033[0m")
+	})
+}
+
+func TestPrettyHandlerReplaceAttr(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := slog.New(logger.NewPrettyHandler(buf, &slog.HandlerOptions{ReplaceAttr: logger.NewRedactor()}))
+
+	log.Error("reset sent to jane@example.com",
+		"password", errors.New("hunter2 rejected"),
+		"error", errors.New("smtp timeout"),
+		"note", "cc bob@example.com",
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "jane@example.com")
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "bob@example.com")
+	assert.Contains(t, out, "password=[REDACTED]")
+	assert.Contains(t, out, "    error: smtp timeout\n")
+
+	buf.Reset()
+	log = slog.New(logger.NewPrettyHandler(buf, &slog.HandlerOptions{ReplaceAttr: logger.WithErrorTrace}))
+	log.Error("failed", "error", fmt.Errorf("loading: %w", errors.New("disk")))
+
+	assert.Equal(t, "failed\n"+
+		"    error: loading: disk\n"+
+		"      *fmt.wrapError\n"+
+		"      caused by: disk\n"+
+		"        *errors.errorString\n",
+		buf.String()[strings.Index(buf.String(), "failed"):])
+}
+
+func TestPrettyHandlerRedactsBlocks(t *testing.T) {
+	jwt := "eyJhbGciOiJFUzUxMiJ9.eyJzdWIiOiJ1In0.c2ln"
+	err := fmt.Errorf("login: %w", errors.Join(
+		fmt.Errorf("token %s expired", jwt),
+		errors.New("notify jane@example.com"),
+	))
+
+	redact := logger.NewRedactor()
+	for name, replaceAttr := range map[string]logger.ReplaceAttrFunc{
+		"redactor":             redact,
+		"error trace redacted": logger.ChainReplaceAttr(logger.WithShortFileNameAndErrorTrace, redact),
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			slog.New(logger.NewPrettyHandler(buf, &slog.HandlerOptions{ReplaceAttr: replaceAttr})).
+				Error("failed", "error", err)
+
+			assert.NotContains(t, buf.String(), "eyJ")
+			assert.NotContains(t, buf.String(), "jane@example.com")
+		})
+	}
+
+	buf := &bytes.Buffer{}
+	slog.New(logger.NewPrettyHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: logger.ChainReplaceAttr(logger.WithShortFileNameAndErrorTrace, redact),
+	})).Error("failed", "error", err)
+
+	assert.Contains(t, buf.String(), "    error: login: token [REDACTED] expired\nnotify [REDACTED]\n")
+	assert.Contains(t, buf.String(), "      caused by: token [REDACTED] expired\n")
+	assert.Contains(t, buf.String(), "      caused by: notify [REDACTED]\n")
+}
+
+func TestWithPrettyFallsBackToJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(logger.WithWriter(buf), logger.WithPretty(true), logger.WithLevel("info"))
+
+	log.Info("piped", "n", 1)
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "piped", entry["msg"])
+}
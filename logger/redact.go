@@ -0,0 +1,329 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// MaskMode is how a redacted value is rendered
+type MaskMode int
+
+const (
+	// MaskFull replaces the value with [REDACTED]
+	MaskFull MaskMode = iota
+	// MaskPartial keeps the last 4 characters
+	MaskPartial
+	// MaskHash replaces the value with a short HMAC-SHA256, to correlate
+	// without revealing it. It requires WithHashKey, values are masked fully
+	// without a key.
+	MaskHash
+)
+
+var (
+	defaultRedactKeys = []string{"password", "passwd", "token", "authorization", "secret", "api_key", "apikey", "cookie"}
+
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	cardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// RedactOption to configure NewRedactor
+type RedactOption func(r *redactor)
+
+type redactor struct {
+	keys    []string
+	values  []*regexp.Regexp
+	mode    MaskMode
+	hashKey []byte
+}
+
+// WithRedactKeys for adding key patterns, a key containing one of them,
+// case-insensitively, has its value redacted
+func WithRedactKeys(patterns ...string) RedactOption {
+	return func(r *redactor) {
+		for _, p := range patterns {
+			r.keys = append(r.keys, strings.ToLower(p))
+		}
+	}
+}
+
+// WithRedactValues for adding value patterns, matches are redacted wherever
+// they appear in string values
+func WithRedactValues(patterns ...*regexp.Regexp) RedactOption {
+	return func(r *redactor) {
+		r.values = append(r.values, patterns...)
+	}
+}
+
+// WithMaskMode for how redacted values are rendered, default is MaskFull
+func WithMaskMode(m MaskMode) RedactOption {
+	return func(r *redactor) {
+		r.mode = m
+	}
+}
+
+// WithHashKey for the secret key of MaskHash. A plain hash of a low-entropy
+// value, such as an email address or an IBAN, is reversed by hashing the
+// candidates, so the key must be kept secret and stay the same for the
+// hashes to correlate across processes.
+func WithHashKey(key []byte) RedactOption {
+	return func(r *redactor) {
+		r.hashKey = key
+	}
+}
+
+// NewRedactor returns a ReplaceAttrFunc redacting
+//   - values of keys named like password, token, authorization or secret
+//   - JWTs, card numbers and email addresses found in string values, and in
+//     errors and fmt.Stringers, which are then replaced by the redacted string
+//   - struct fields tagged `log:"redact"`, `log:"redact,partial"` or
+//     `log:"redact,hash"`, and omitting fields tagged `log:"-"`
+//
+// Compose it with other ReplaceAttrFuncs, e.g. WithShortFileNameAndErrorTrace.
+func NewRedactor(opts ...RedactOption) ReplaceAttrFunc {
+	r := &redactor{
+		keys: defaultRedactKeys,
+		values: []*regexp.Regexp{
+			jwtPattern,
+			cardPattern,
+			emailPattern,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r.replaceAttr
+}
+
+func (r *redactor) replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if r.sensitiveKey(a.Key) {
+		a.Value = slog.StringValue(r.mask(a.Value.String(), r.mode))
+		return a
+	}
+
+	a.Value = r.redactValue(a.Value)
+	return a
+}
+
+func (r *redactor) redactValue(v slog.Value) slog.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.StringValue(r.redactString(v.String()))
+	case slog.KindGroup:
+		attrs := v.Group()
+		out := make([]slog.Attr, len(attrs))
+		for i, a := range attrs {
+			out[i] = r.replaceAttr(nil, a)
+		}
+		return slog.GroupValue(out...)
+	case slog.KindAny:
+		if tagged := r.taggedStruct(v.Any()); tagged != nil {
+			return *tagged
+		}
+
+		// errors and Stringers are kept as is unless they have to be
+		// redacted, e.g. to keep the trace of an error
+		var s string
+		switch x := v.Any().(type) {
+		case error:
+			s = x.Error()
+		case fmt.Stringer:
+			s = x.String()
+		default:
+			return v
+		}
+
+		if redacted := r.redactString(s); redacted != s {
+			return slog.StringValue(redacted)
+		}
+	}
+
+	return v
+}
+
+func (r *redactor) redactString(s string) string {
+	for _, re := range r.values {
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			if re == cardPattern && !luhn(match) {
+				return match
+			}
+
+			return r.mask(match, r.mode)
+		})
+	}
+
+	return s
+}
+
+func (r *redactor) sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldRule is how a struct field with a `log` tag is rendered
+type fieldRule struct {
+	index int
+	name  string
+	omit  bool
+	mask  *MaskMode
+}
+
+// structRules caches, per struct type, the rules of its exported fields, or
+// nil when no field has a `log` tag
+var structRules sync.Map
+
+// taggedStruct renders a struct with `log` tagged fields as a group
+func (r *redactor) taggedStruct(v any) *slog.Value {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	rules := rulesFor(rv.Type())
+	if rules == nil {
+		return nil
+	}
+
+	attrs := make([]slog.Attr, 0, len(rules))
+	for _, rule := range rules {
+		if rule.omit {
+			continue
+		}
+
+		field := rv.Field(rule.index)
+		if rule.mask != nil {
+			mode := *rule.mask
+			if mode < 0 {
+				mode = r.mode
+			}
+
+			attrs = append(attrs, slog.String(rule.name, r.mask(slog.AnyValue(field.Interface()).String(), mode)))
+			continue
+		}
+
+		attrs = append(attrs, r.replaceAttr(nil, slog.Any(rule.name, field.Interface())))
+	}
+
+	value := slog.GroupValue(attrs...)
+	return &value
+}
+
+func rulesFor(t reflect.Type) []fieldRule {
+	if cached, ok := structRules.Load(t); ok {
+		return cached.([]fieldRule)
+	}
+
+	var rules []fieldRule
+	tagged := false
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		rule := fieldRule{index: i, name: f.Name}
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+			rule.name = name
+		}
+
+		tag, ok := f.Tag.Lookup("log")
+		if ok {
+			tagged = true
+
+			switch tag {
+			case "-":
+				rule.omit = true
+			case "redact":
+				// the redactor mode
+				mode := MaskMode(-1)
+				rule.mask = &mode
+			case "redact,full":
+				mode := MaskFull
+				rule.mask = &mode
+			case "redact,partial":
+				mode := MaskPartial
+				rule.mask = &mode
+			case "redact,hash":
+				mode := MaskHash
+				rule.mask = &mode
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if !tagged {
+		rules = nil
+	}
+
+	structRules.Store(t, rules)
+	return rules
+}
+
+func (r *redactor) mask(s string, mode MaskMode) string {
+	switch mode {
+	case MaskPartial:
+		runes := []rune(s)
+		if len(runes) <= 8 {
+			return strings.Repeat("*", len(runes))
+		}
+		return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+	case MaskHash:
+		if len(r.hashKey) == 0 {
+			break
+		}
+		mac := hmac.New(sha256.New, r.hashKey)
+		mac.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:16])
+	}
+
+	return "[REDACTED]"
+}
+
+// luhn checks the card number checksum, to leave other long numbers alone
+func luhn(s string) bool {
+	sum, double := 0, false
+
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0
+}
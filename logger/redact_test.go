@@ -0,0 +1,118 @@
+package logger_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+type customer struct {
+	ID       string `json:"id"`
+	Name     string `json:"name" log:"redact,partial"`
+	Password string `json:"password" log:"-"`
+	IBAN     string `json:"iban" log:"redact,hash"`
+	Notes    string `json:"notes" log:"redact"`
+}
+
+func TestRedactor(t *testing.T) {
+	redact := logger.NewRedactor(
+		logger.WithRedactKeys("ssn"),
+		logger.WithRedactValues(regexp.MustCompile(`sk_live_\w+`)),
+		logger.WithHashKey([]byte("test key")),
+	)
+
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(
+		logger.WithWriter(buf),
+		logger.WithJSON(true),
+		logger.WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+			return redact(groups, logger.WithShortFileNameAndErrorTrace(groups, a))
+		}),
+	)
+
+	log.Warn("login with key sk_live_abc123",
+		slog.String("Authorization", "Bearer abc"),
+		slog.Group("user", slog.String("db_password", "pass-123"), slog.String("ssn", "123-45-6789")),
+		slog.String("jwt", "token is eyJhbGciOiJFUzUxMiJ9.eyJzdWIiOiJ1In0.c2ln"),
+		slog.String("card", "paid with 4111 1111 1111 1111"),
+		slog.String("order", "order 1234567890123"),
+		slog.String("contact", "write to jan@example.com"),
+		slog.Any("customer", customer{ID: "c-1", Name: "Jan Doe Smith", Password: "pass-123", IBAN: "DE89370400440532013000", Notes: "vip"}),
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"sk_live_abc123", "Bearer abc", "pass-123", "123-45-6789", "eyJ", "4111 1111", "jan@example.com", "DE89", "vip", "Jan Doe"} {
+		assert.NotContains(t, out, secret)
+	}
+
+	assert.Contains(t, out, `"msg":"login with key [REDACTED]"`)
+	assert.Contains(t, out, `"card":"paid with [REDACTED]"`)
+	assert.Contains(t, out, `"order":"order 1234567890123"`)
+	assert.Contains(t, out, `"customer":{"id":"c-1","name":"*********mith","iban":"hmac:`)
+	assert.Contains(t, out, `"notes":"[REDACTED]"`)
+}
+
+func TestRedactorMaskModes(t *testing.T) {
+	partial := logger.NewRedactor(logger.WithMaskMode(logger.MaskPartial))
+	assert.Equal(t, "********5678", partial(nil, slog.String("api_token", "abcd12345678")).Value.String())
+	assert.Equal(t, "****", partial(nil, slog.String("token", "abcd")).Value.String())
+
+	hash := logger.NewRedactor(logger.WithMaskMode(logger.MaskHash), logger.WithHashKey([]byte("key 1")))
+	first := hash(nil, slog.String("secret", "value")).Value.String()
+	assert.Equal(t, first, hash(nil, slog.String("secret", "value")).Value.String())
+	assert.Regexp(t, `^hmac:[0-9a-f]{32}$`, first)
+
+	// the hash depends on the key, and is not the plain sha256 of the value
+	other := logger.NewRedactor(logger.WithMaskMode(logger.MaskHash), logger.WithHashKey([]byte("key 2")))
+	assert.NotEqual(t, first, other(nil, slog.String("secret", "value")).Value.String())
+	sum := sha256.Sum256([]byte("value"))
+	assert.NotContains(t, first, hex.EncodeToString(sum[:8]))
+
+	noKey := logger.NewRedactor(logger.WithMaskMode(logger.MaskHash))
+	assert.Equal(t, "[REDACTED]", noKey(nil, slog.String("secret", "value")).Value.String())
+}
+
+type endpoint struct {
+	url string
+}
+
+func (e endpoint) String() string {
+	return e.url
+}
+
+func TestRedactorErrorsAndStringers(t *testing.T) {
+	redact := logger.NewRedactor()
+	jwt := "eyJhbGciOiJFUzUxMiJ9.eyJzdWIiOiJ1In0.c2ln"
+
+	a := redact(nil, slog.Any("error", fmt.Errorf("auth %s: %w", jwt, errors.New("expired"))))
+	assert.Equal(t, slog.KindString, a.Value.Kind())
+	assert.Equal(t, "auth [REDACTED]: expired", a.Value.String())
+
+	a = redact(nil, slog.Any("endpoint", endpoint{url: "smtp://jane@example.com:25"}))
+	assert.Equal(t, "smtp://[REDACTED]:25", a.Value.String())
+
+	// values with nothing to redact are kept, e.g. for WithErrorTrace
+	err := errors.New("timeout")
+	a = redact(nil, slog.Any("error", err))
+	assert.Equal(t, err, a.Value.Any())
+
+	// redacted before being rendered as a trace
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(
+		logger.WithWriter(buf),
+		logger.WithJSON(true),
+		logger.WithReplaceAttr(logger.ChainReplaceAttr(redact, logger.WithShortFileNameAndErrorTrace)),
+	)
+	log.Warn("login failed", "error", fmt.Errorf("login: %w", fmt.Errorf("token %s", jwt)))
+	assert.NotContains(t, buf.String(), "eyJ")
+	assert.Contains(t, buf.String(), `"error":"login: token [REDACTED]"`)
+}
@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"log/slog"
+	"strings"
+)
+
+var (
+	// GCPSchema renders records the way Google Cloud Logging expects them
+	GCPSchema = ChainReplaceAttr(
+		LevelNames(map[slog.Level]string{
+			LevelTrace:     "DEBUG",
+			slog.LevelWarn: "WARNING",
+			LevelFatal:     "CRITICAL",
+		}),
+		RenameKey(slog.MessageKey, "message"),
+		RenameKey(slog.LevelKey, "severity"),
+		RenameKey(slog.SourceKey, "logging.googleapis.com/sourceLocation"),
+	)
+
+	// ECSSchema renders records following the Elastic Common Schema
+	ECSSchema = ChainReplaceAttr(
+		LevelNames(map[slog.Level]string{
+			LevelTrace:      "trace",
+			slog.LevelDebug: "debug",
+			slog.LevelInfo:  "info",
+			slog.LevelWarn:  "warn",
+			slog.LevelError: "error",
+			LevelFatal:      "fatal",
+		}),
+		RenameKey(slog.TimeKey, "@timestamp"),
+		RenameKey(slog.MessageKey, "message"),
+		RenameKey(slog.LevelKey, "log.level"),
+		RenameKey(slog.SourceKey, "log.origin"),
+	)
+)
+
+// ChainReplaceAttr runs the functions in order, each one getting the attr
+// returned by the previous one. It stops once an attr is dropped.
+func ChainReplaceAttr(fs ...ReplaceAttrFunc) ReplaceAttrFunc {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		for _, f := range fs {
+			a = f(groups, a)
+			if a.Equal(slog.Attr{}) {
+				return a
+			}
+		}
+
+		return a
+	}
+}
+
+// RenameKey renames the attr at path, a key for top level attrs, e.g. "msg",
+// or the groups and key joined by dots, e.g. "request.headers"
+func RenameKey(path, to string) ReplaceAttrFunc {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if matchAttrPath(groups, a.Key, path) {
+			a.Key = to
+		}
+
+		return a
+	}
+}
+
+// DropKeys drops the attrs at the paths, see RenameKey for the path format
+func DropKeys(paths ...string) ReplaceAttrFunc {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		for _, p := range paths {
+			if matchAttrPath(groups, a.Key, p) {
+				return slog.Attr{}
+			}
+		}
+
+		return a
+	}
+}
+
+// TimeUTC converts every time value to UTC
+func TimeUTC() ReplaceAttrFunc {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if a.Value.Kind() == slog.KindTime {
+			a.Value = slog.TimeValue(a.Value.Time().UTC())
+		}
+
+		return a
+	}
+}
+
+// TimeFormat renders every time value with the layout, e.g. time.RFC3339,
+// use it after TimeUTC to get UTC times
+func TimeFormat(layout string) ReplaceAttrFunc {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if a.Value.Kind() == slog.KindTime {
+			a.Value = slog.StringValue(a.Value.Time().Format(layout))
+		}
+
+		return a
+	}
+}
+
+// LevelNames renders the level of the record with the given names, the
+// others keep the name given by LevelName. Other attrs holding a level are
+// left alone. Put it before a RenameKey of the level key.
+func LevelNames(names map[slog.Level]string) ReplaceAttrFunc {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key != slog.LevelKey || len(groups) > 0 || a.Value.Kind() != slog.KindAny {
+			return a
+		}
+
+		if l, ok := a.Value.Any().(slog.Level); ok {
+			name, ok := names[l]
+			if !ok {
+				name = LevelName(l)
+			}
+
+			a.Value = slog.StringValue(name)
+		}
+
+		return a
+	}
+}
+
+func matchAttrPath(groups []string, key, path string) bool {
+	if len(groups) == 0 {
+		return key == path
+	}
+
+	return strings.Join(groups, ".")+"."+key == path
+}
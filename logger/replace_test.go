@@ -0,0 +1,69 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+func TestChainReplaceAttr(t *testing.T) {
+	newLogger := func(buf *bytes.Buffer, f logger.ReplaceAttrFunc) *slog.Logger {
+		return logger.NewLogger(
+			logger.WithWriter(buf),
+			logger.WithJSON(true),
+			logger.WithLevel("trace"),
+			logger.WithReplaceAttr(f),
+		)
+	}
+
+	t.Run("transforms", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := newLogger(buf, logger.ChainReplaceAttr(
+			logger.TimeUTC(),
+			logger.TimeFormat(time.DateOnly),
+			logger.RenameKey("msg", "message"),
+			logger.RenameKey("req.id", "request_id"),
+			logger.DropKeys("time", "req.debug"),
+			logger.LevelNames(map[slog.Level]string{slog.LevelWarn: "warning"}),
+		))
+
+		created := time.Date(2024, 5, 18, 23, 30, 0, 0, time.FixedZone("IST", 19800))
+		log.Warn("hello", slog.Time("created", created), slog.Group("req", "id", "r-1", "debug", true),
+			slog.Any("min", slog.LevelWarn), slog.Group("g", slog.Any("level", slog.LevelWarn)))
+
+		assert.Equal(t,
+			`{"level":"warning","message":"hello","created":"2024-05-18","req":{"request_id":"r-1"},"min":"WARN","g":{"level":"WARN"}}`+"\n",
+			buf.String())
+	})
+
+	t.Run("gcp", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		newLogger(buf, logger.ChainReplaceAttr(logger.DropKeys("time"), logger.GCPSchema)).Warn("hello")
+		assert.Equal(t, `{"severity":"WARNING","message":"hello"}`+"\n", buf.String())
+	})
+
+	t.Run("ecs", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		newLogger(buf, logger.ECSSchema).Log(context.Background(), logger.LevelTrace, "hello")
+		assert.Contains(t, buf.String(), `"log.level":"trace","message":"hello"`)
+		assert.Contains(t, buf.String(), `"@timestamp":`)
+	})
+
+	t.Run("custom level names survive renaming", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		newLogger(buf, logger.RenameKey("level", "lvl")).Log(context.Background(), logger.LevelFatal, "bye")
+		assert.Contains(t, buf.String(), `"lvl":"FATAL"`)
+	})
+
+	t.Run("other level attrs keep the slog names", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		newLogger(buf, logger.DropKeys("time")).Warn("threshold", slog.Any("min_level", logger.LevelTrace))
+		assert.Equal(t, `{"level":"WARN","msg":"threshold","min_level":"DEBUG-4"}`+"\n", buf.String())
+	})
+}
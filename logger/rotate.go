@@ -0,0 +1,357 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat is appended, in UTC, to the file name of rotated files
+const backupTimeFormat = "20060102T150405.000"
+
+// RotatingFileOption to configure RotatingFile
+type RotatingFileOption func(r *RotatingFile)
+
+// RotatingFile is an io.Writer for WithWriter or a Sink, writing to a file
+// rotated by size and/or age. Ages are counted in wall-clock periods, e.g.
+// from midnight UTC for a day, so that reopening the file or restarting the
+// process doesn't extend them. It is safe for concurrent writes.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	period  time.Time
+	closed  bool
+	rotated time.Time
+	now     func() time.Time
+	stop    chan struct{}
+
+	// compression and cleanup of backups run one at a time, off the write path
+	bgMu sync.Mutex
+	wg   sync.WaitGroup
+}
+
+// WithRotateMaxSize for rotating once the file reaches n bytes, default is 100MB, 0 disables it
+func WithRotateMaxSize(n int64) RotatingFileOption {
+	return func(r *RotatingFile) {
+		r.maxSize = n
+	}
+}
+
+// WithRotateMaxAge for rotating the file at the end of each period of d,
+// e.g. daily at midnight UTC for 24h, default is 0 (disabled). The period of
+// an existing file is the one of its last write.
+func WithRotateMaxAge(d time.Duration) RotatingFileOption {
+	return func(r *RotatingFile) {
+		r.maxAge = d
+	}
+}
+
+// WithRotateMaxBackups for the number of rotated files kept, default is 7, 0 keeps all
+func WithRotateMaxBackups(n int) RotatingFileOption {
+	return func(r *RotatingFile) {
+		r.maxBackups = n
+	}
+}
+
+// WithRotateCompress for gzipping rotated files
+func WithRotateCompress(b bool) RotatingFileOption {
+	return func(r *RotatingFile) {
+		r.compress = b
+	}
+}
+
+// WithRotateReopenOnSIGHUP for reopening the file on SIGHUP, for when an
+// external tool moved it away
+func WithRotateReopenOnSIGHUP(b bool) RotatingFileOption {
+	return func(r *RotatingFile) {
+		if b {
+			r.stop = make(chan struct{})
+		}
+	}
+}
+
+// NewRotatingFile opens, or creates, the file at path for appending
+func NewRotatingFile(path string, opts ...RotatingFileOption) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    100 << 20,
+		maxBackups: 7,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	if r.stop != nil {
+		sighup := make(chan os.Signal, 1)
+		signal.Notify(sighup, syscall.SIGHUP)
+
+		go func() {
+			defer signal.Stop(sighup)
+
+			for {
+				select {
+				case <-sighup:
+					_ = r.Reopen()
+				case <-r.stop:
+					return
+				}
+			}
+		}()
+	}
+
+	return r, nil
+}
+
+// Write appends p to the file, rotating it first when p would not fit or the
+// file is too old. A single write is never split across files.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	tooBig := r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize
+	tooOld := r.maxAge > 0 && r.now().Truncate(r.maxAge).After(r.period)
+	if tooBig || tooOld {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// Rotate rotates the file now
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+
+	return r.rotate()
+}
+
+// Reopen closes and reopens the file at path, without rotating it
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return fmt.Errorf("failed to close log file: %w", err)
+		}
+		r.file = nil
+	}
+
+	return r.open()
+}
+
+// Close closes the file and waits for pending compressions
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	if r.stop != nil {
+		close(r.stop)
+	}
+
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+
+	r.wg.Wait()
+
+	return err
+}
+
+// open must be called with mu held
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	r.file = f
+	r.size = info.Size()
+
+	// a reopened or restarted file keeps the period of its last write
+	r.period = r.now()
+	if r.size > 0 {
+		r.period = info.ModTime()
+	}
+	if r.maxAge > 0 {
+		r.period = r.period.Truncate(r.maxAge)
+	}
+
+	return nil
+}
+
+// rotate must be called with mu held
+func (r *RotatingFile) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return fmt.Errorf("failed to close log file: %w", err)
+		}
+		r.file = nil
+	}
+
+	// backup names must be unique and sort in rotation order, even for
+	// rotations within the same millisecond
+	ts := r.now().Truncate(time.Millisecond)
+	if !ts.After(r.rotated) {
+		ts = r.rotated.Add(time.Millisecond)
+	}
+	r.rotated = ts
+
+	ext := filepath.Ext(r.path)
+	backup := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(r.path, ext), ts.UTC().Format(backupTimeFormat), ext)
+	if err := os.Rename(r.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rename log file: %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		r.bgMu.Lock()
+		defer r.bgMu.Unlock()
+
+		if r.compress {
+			_ = compressFile(backup)
+		}
+		r.removeOldBackups()
+	}()
+
+	return nil
+}
+
+// removeOldBackups keeps the newest maxBackups rotated files
+func (r *RotatingFile) removeOldBackups() {
+	if r.maxBackups <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(r.path))
+	if err != nil {
+		return
+	}
+
+	var matches []string
+	for _, e := range entries {
+		if !e.IsDir() && r.isBackup(e.Name()) {
+			matches = append(matches, filepath.Join(filepath.Dir(r.path), e.Name()))
+		}
+	}
+
+	// the timestamp format sorts in chronological order
+	sort.Strings(matches)
+	for len(matches) > r.maxBackups {
+		_ = os.Remove(matches[0])
+		matches = matches[1:]
+	}
+}
+
+// isBackup reports whether name is the name of a rotated file, as created by
+// rotate and compressFile, and not only a file with the same prefix
+func (r *RotatingFile) isBackup(name string) bool {
+	ext := filepath.Ext(r.path)
+
+	ts, ok := strings.CutPrefix(name, strings.TrimSuffix(filepath.Base(r.path), ext)+"-")
+	if !ok {
+		return false
+	}
+
+	ts, ok = strings.CutSuffix(strings.TrimSuffix(ts, ".gz"), ext)
+	if !ok {
+		return false
+	}
+
+	_, err := time.Parse(backupTimeFormat, ts)
+
+	return err == nil
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,169 @@
+package logger_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("rotates by size and keeps backups", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		r, err := logger.NewRotatingFile(path, logger.WithRotateMaxSize(20), logger.WithRotateMaxBackups(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 5; i++ {
+			_, err := r.Write([]byte("0123456789abcdef\n"))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, r.Close())
+
+		backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+		assert.Len(t, backups, 2)
+
+		data, _ := os.ReadFile(path)
+		assert.Equal(t, "0123456789abcdef\n", string(data))
+
+		_, err = r.Write([]byte("closed"))
+		assert.ErrorIs(t, err, os.ErrClosed)
+	})
+
+	t.Run("keeps unrelated files", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		siblings := []string{"app-debug.log", "app-2.log.bak", "app-20240501T123000.log"}
+		for _, name := range siblings {
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("keep"), 0o644))
+		}
+
+		r, err := logger.NewRotatingFile(path, logger.WithRotateMaxSize(20), logger.WithRotateMaxBackups(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 4; i++ {
+			_, err := r.Write([]byte("0123456789abcdef\n"))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, r.Close())
+
+		for _, name := range siblings {
+			assert.FileExists(t, filepath.Join(dir, name))
+		}
+
+		backups, _ := filepath.Glob(filepath.Join(dir, "app-*T*.*.log"))
+		assert.Len(t, backups, 1)
+	})
+
+	t.Run("rotates by age across restarts", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		// written by a previous process, in a previous period
+		assert.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+		old := time.Now().Add(-48 * time.Hour)
+		assert.NoError(t, os.Chtimes(path, old, old))
+
+		r, err := logger.NewRotatingFile(path, logger.WithRotateMaxAge(24*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = r.Write([]byte("new\n"))
+		assert.NoError(t, err)
+
+		// reopening within the period keeps the file
+		assert.NoError(t, r.Reopen())
+		_, err = r.Write([]byte("again\n"))
+		assert.NoError(t, err)
+		assert.NoError(t, r.Close())
+
+		data, _ := os.ReadFile(path)
+		assert.Equal(t, "new\nagain\n", string(data))
+
+		backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+		if assert.Len(t, backups, 1) {
+			data, _ = os.ReadFile(backups[0])
+			assert.Equal(t, "old\n", string(data))
+		}
+	})
+
+	t.Run("compresses backups", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		r, err := logger.NewRotatingFile(path, logger.WithRotateCompress(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _ = r.Write([]byte("first\n"))
+		assert.NoError(t, r.Rotate())
+		_, _ = r.Write([]byte("second\n"))
+		assert.NoError(t, r.Close())
+
+		backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+		if assert.Len(t, backups, 1) {
+			f, _ := os.Open(backups[0])
+			defer f.Close()
+
+			gz, err := gzip.NewReader(f)
+			assert.NoError(t, err)
+			data, _ := io.ReadAll(gz)
+			assert.Equal(t, "first\n", string(data))
+		}
+	})
+
+	t.Run("reopen and concurrent writes", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		r, err := logger.NewRotatingFile(path, logger.WithRotateMaxSize(512), logger.WithRotateMaxBackups(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					_, _ = r.Write([]byte("line\n"))
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.NoError(t, os.Rename(path, path+".moved"))
+		assert.NoError(t, r.Reopen())
+		_, _ = r.Write([]byte("after\n"))
+		assert.NoError(t, r.Close())
+
+		data, _ := os.ReadFile(path)
+		assert.Equal(t, "after\n", string(data))
+
+		var all bytes.Buffer
+		files, _ := filepath.Glob(filepath.Join(dir, "app*"))
+		for _, f := range files {
+			b, _ := os.ReadFile(f)
+			all.Write(b)
+		}
+		assert.Equal(t, 401, strings.Count(all.String(), "\n"))
+	})
+}
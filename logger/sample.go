@@ -0,0 +1,282 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingOption to configure SamplingHandler
+type SamplingOption func(s *samplingState)
+
+type sampleRate struct {
+	first      int
+	thereafter int
+}
+
+// WithSampleRate for letting through the first records with the same level
+// and message in each interval, then every thereafter-th. 0 for thereafter
+// drops the rest. Sampling is disabled by default.
+func WithSampleRate(first, thereafter int) SamplingOption {
+	return func(s *samplingState) {
+		s.rate = &sampleRate{first: first, thereafter: thereafter}
+	}
+}
+
+// WithLevelSampleRate for a level specific sample rate, see WithSampleRate
+func WithLevelSampleRate(level slog.Level, first, thereafter int) SamplingOption {
+	return func(s *samplingState) {
+		s.levelRates[level] = sampleRate{first: first, thereafter: thereafter}
+	}
+}
+
+// WithSampleInterval for the sampling interval, default is 1 second
+func WithSampleInterval(d time.Duration) SamplingOption {
+	return func(s *samplingState) {
+		s.interval = d
+	}
+}
+
+// WithDedupWindow for dropping records identical to one logged less than d
+// ago, the number of dropped ones is logged once the window ends.
+// Deduplication is disabled by default.
+func WithDedupWindow(d time.Duration) SamplingOption {
+	return func(s *samplingState) {
+		s.window = d
+	}
+}
+
+// WithPassLevel for letting every record at or above the level through,
+// e.g. slog.LevelError
+func WithPassLevel(l slog.Level) SamplingOption {
+	return func(s *samplingState) {
+		s.passLevel = l
+	}
+}
+
+// WithSampling for sampling and deduplicating records, see SamplingHandler
+func WithSampling(opts ...SamplingOption) func(*CustomLogger) {
+	return func(cl *CustomLogger) {
+		cl.sampling = true
+		cl.samplingOpts = opts
+	}
+}
+
+// dedupEntry tracks the records identical to one let through
+type dedupEntry struct {
+	handler  slog.Handler
+	last     slog.Record
+	repeated int
+}
+
+// samplingState is shared by a SamplingHandler and the handlers derived from it
+type samplingState struct {
+	rate       *sampleRate
+	levelRates map[slog.Level]sampleRate
+	interval   time.Duration
+	window     time.Duration
+	passLevel  slog.Level
+
+	mu      sync.Mutex
+	tick    time.Time
+	counts  map[string]int
+	dedup   map[string]*dedupEntry
+	timers  map[string]*time.Timer
+	dropped atomic.Uint64
+}
+
+// SamplingHandler drops part of the records of hot code paths. Records with
+// the same level and message are sampled per interval, and records identical
+// to a recent one, attrs included, are counted instead of written.
+type SamplingHandler struct {
+	handler slog.Handler
+	// prefix identifies the attrs and groups added to this handler
+	prefix string
+	state  *samplingState
+}
+
+// NewSamplingHandler to sample and deduplicate the records handed to h
+func NewSamplingHandler(h slog.Handler, opts ...SamplingOption) *SamplingHandler {
+	s := &samplingState{
+		levelRates: make(map[slog.Level]sampleRate),
+		interval:   time.Second,
+		passLevel:  slog.Level(math.MaxInt32),
+		counts:     make(map[string]int),
+		dedup:      make(map[string]*dedupEntry),
+		timers:     make(map[string]*time.Timer),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return &SamplingHandler{handler: h, state: s}
+}
+
+func (s *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.handler.Enabled(ctx, level)
+}
+
+func (s *SamplingHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if rec.Level < s.state.passLevel && !s.keep(rec) {
+		return nil
+	}
+
+	return s.handler.Handle(ctx, rec)
+}
+
+func (s *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(s.prefix)
+	for _, a := range attrs {
+		writeAttrKey(&b, a)
+	}
+
+	return &SamplingHandler{handler: s.handler.WithAttrs(attrs), prefix: b.String(), state: s.state}
+}
+
+func (s *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{handler: s.handler.WithGroup(name), prefix: s.prefix + name + "{", state: s.state}
+}
+
+// Dropped returns the number of records dropped by sampling or deduplication
+func (s *SamplingHandler) Dropped() uint64 {
+	return s.state.dropped.Load()
+}
+
+// Flush writes the pending "repeated" summaries now
+func (s *SamplingHandler) Flush(ctx context.Context) error {
+	st := s.state
+
+	st.mu.Lock()
+	pending := make([]*dedupEntry, 0, len(st.dedup))
+	for key, entry := range st.dedup {
+		if t := st.timers[key]; t != nil {
+			t.Stop()
+		}
+		pending = append(pending, entry)
+	}
+	st.dedup = make(map[string]*dedupEntry)
+	st.timers = make(map[string]*time.Timer)
+	st.mu.Unlock()
+
+	for _, entry := range pending {
+		entry.summarize(ctx)
+	}
+
+	return nil
+}
+
+// keep decides whether the record is written. Only the records let through
+// by sampling open a dedup window.
+func (s *SamplingHandler) keep(rec slog.Record) bool {
+	st := s.state
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var key string
+	if st.window > 0 {
+		key = s.dedupKey(rec)
+		if entry, ok := st.dedup[key]; ok {
+			entry.repeated++
+			entry.last = rec.Clone()
+			st.dropped.Add(1)
+			return false
+		}
+	}
+
+	if !st.sample(rec) {
+		st.dropped.Add(1)
+		return false
+	}
+
+	if st.window > 0 {
+		st.track(key, &dedupEntry{handler: s.handler})
+	}
+
+	return true
+}
+
+// sample decides whether the record is let through by the sample rate,
+// st.mu is held
+func (st *samplingState) sample(rec slog.Record) bool {
+	rate, ok := st.levelRates[rec.Level]
+	if !ok {
+		if st.rate == nil {
+			return true
+		}
+		rate = *st.rate
+	}
+
+	now := time.Now()
+	if now.Sub(st.tick) >= st.interval {
+		st.tick = now
+		clear(st.counts)
+	}
+
+	key := strconv.Itoa(int(rec.Level)) + "|" + rec.Message
+	st.counts[key]++
+	n := st.counts[key]
+
+	return n <= rate.first || rate.thereafter > 0 && (n-rate.first)%rate.thereafter == 0
+}
+
+// track opens the dedup window of entry, st.mu is held
+func (st *samplingState) track(key string, entry *dedupEntry) {
+	st.dedup[key] = entry
+	st.timers[key] = time.AfterFunc(st.window, func() {
+		st.mu.Lock()
+		// Flush may have summarized it already
+		current := st.dedup[key] == entry
+		if current {
+			delete(st.dedup, key)
+			delete(st.timers, key)
+		}
+		st.mu.Unlock()
+
+		if current {
+			entry.summarize(context.Background())
+		}
+	})
+}
+
+func (s *SamplingHandler) dedupKey(rec slog.Record) string {
+	var b strings.Builder
+	b.WriteString(s.prefix)
+	b.WriteString(strconv.Itoa(int(rec.Level)))
+	b.WriteByte('|')
+	b.WriteString(rec.Message)
+	b.WriteByte('|')
+
+	rec.Attrs(func(a slog.Attr) bool {
+		writeAttrKey(&b, a)
+		return true
+	})
+
+	return b.String()
+}
+
+func writeAttrKey(b *strings.Builder, a slog.Attr) {
+	b.WriteString(a.Key)
+	b.WriteByte('=')
+	b.WriteString(a.Value.Resolve().String())
+	b.WriteByte(';')
+}
+
+// summarize writes the last dropped record with the number of repetitions
+func (e *dedupEntry) summarize(ctx context.Context) {
+	if e.repeated == 0 {
+		return
+	}
+
+	rec := e.last.Clone()
+	rec.AddAttrs(slog.Int("repeated", e.repeated))
+
+	_ = e.handler.Handle(ctx, rec)
+}
@@ -0,0 +1,118 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+// syncBuffer is a bytes.Buffer safe for the writes of dedup timers
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+func TestSamplingHandler(t *testing.T) {
+	t.Run("first then every nth", func(t *testing.T) {
+		buf := &syncBuffer{}
+		h := logger.NewSamplingHandler(slog.NewTextHandler(buf, nil),
+			logger.WithSampleRate(3, 10),
+			logger.WithSampleInterval(time.Hour),
+			logger.WithPassLevel(slog.LevelError),
+		)
+		log := slog.New(h)
+
+		for i := 0; i < 100; i++ {
+			log.Info("hot path", "i", i)
+			log.Warn("other path")
+		}
+		log.Error("failure")
+		log.Error("failure")
+
+		// 3 first, then the 13th, 23rd, ... 93rd
+		assert.Equal(t, 3+9, strings.Count(buf.String(), "hot path"))
+		assert.Contains(t, buf.String(), "i=12\n")
+		assert.Equal(t, 3+9, strings.Count(buf.String(), "other path"))
+		assert.Equal(t, 2, strings.Count(buf.String(), "failure"))
+		assert.Equal(t, uint64(2*88), h.Dropped())
+	})
+
+	t.Run("level rates", func(t *testing.T) {
+		buf := &syncBuffer{}
+		log := slog.New(logger.NewSamplingHandler(slog.NewTextHandler(buf, nil),
+			logger.WithLevelSampleRate(slog.LevelInfo, 1, 0),
+		))
+
+		for i := 0; i < 10; i++ {
+			log.Info("info")
+			log.Warn("warn")
+		}
+
+		assert.Equal(t, 1, strings.Count(buf.String(), "msg=info"))
+		assert.Equal(t, 10, strings.Count(buf.String(), "msg=warn"))
+	})
+
+	t.Run("dedup", func(t *testing.T) {
+		buf := &syncBuffer{}
+		log := logger.NewLogger(
+			logger.WithWriter(buf),
+			logger.WithSampling(logger.WithDedupWindow(50*time.Millisecond)),
+		)
+
+		for i := 0; i < 5; i++ {
+			log.Warn("disk full", "disk", "sda")
+			log.With("disk", "sdb").Warn("disk full")
+		}
+
+		assert.Equal(t, 2, strings.Count(buf.String(), "disk full"))
+
+		assert.Eventually(t, func() bool {
+			return strings.Count(buf.String(), "repeated=4") == 2
+		}, time.Second, 10*time.Millisecond)
+
+		log.Warn("disk full", "disk", "sda")
+		log.Warn("disk full", "disk", "sda")
+		assert.NoError(t, logger.Flush(context.Background(), log))
+		assert.Contains(t, buf.String(), "repeated=1")
+	})
+
+	t.Run("dedup after sampling", func(t *testing.T) {
+		buf := &syncBuffer{}
+		log := slog.New(logger.NewSamplingHandler(slog.NewTextHandler(buf, nil),
+			logger.WithSampleRate(1, 0),
+			logger.WithSampleInterval(time.Hour),
+			logger.WithDedupWindow(time.Hour),
+		))
+
+		for i := 0; i < 3; i++ {
+			log.Info("retry", "attempt", 1)
+			log.Info("retry", "attempt", 2)
+		}
+		assert.NoError(t, logger.Flush(context.Background(), log))
+
+		// the sampled out records have no summary, of a record never written
+		assert.Equal(t, 2, strings.Count(buf.String(), "msg=retry"))
+		assert.Contains(t, buf.String(), "attempt=1 repeated=2")
+		assert.NotContains(t, buf.String(), "attempt=2")
+	})
+}
@@ -0,0 +1,497 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPOption to configure HTTPHandler
+type HTTPOption func(s *shipper)
+
+// WithBatchSize for the number of records sent per request, default is 100
+func WithBatchSize(n int) HTTPOption {
+	return func(s *shipper) {
+		s.batchSize = n
+	}
+}
+
+// WithFlushInterval for how often pending records are sent, default is 1s
+func WithFlushInterval(d time.Duration) HTTPOption {
+	return func(s *shipper) {
+		s.interval = d
+	}
+}
+
+// WithNDJSON for sending one JSON object per line instead of a JSON array
+func WithNDJSON(b bool) HTTPOption {
+	return func(s *shipper) {
+		s.contentType, s.encode = "application/json", encodeJSONArray
+		if b {
+			s.contentType, s.encode = "application/x-ndjson", encodeNDJSON
+		}
+	}
+}
+
+// WithHeader for adding a header to the requests, e.g. Authorization
+func WithHeader(key, value string) HTTPOption {
+	return func(s *shipper) {
+		s.headers.Add(key, value)
+	}
+}
+
+// WithHTTPClient for the client sending the requests, default has a 10s timeout
+func WithHTTPClient(c *http.Client) HTTPOption {
+	return func(s *shipper) {
+		s.client = c
+	}
+}
+
+// WithRetries for how many times a failed request is retried, with an
+// exponential backoff starting at backoff, default is 3 times from 500ms
+func WithRetries(n int, backoff time.Duration) HTTPOption {
+	return func(s *shipper) {
+		s.retries = n
+		s.backoff = backoff
+	}
+}
+
+// WithSpoolDir for keeping the batches that could not be sent in dir, they
+// are sent again once the endpoint is back. By default they are dropped.
+func WithSpoolDir(dir string) HTTPOption {
+	return func(s *shipper) {
+		s.spoolDir = dir
+	}
+}
+
+// WithMaxSpoolSize for the size of the spool directory above which the
+// oldest batches are dropped, default is 100MB
+func WithMaxSpoolSize(n int64) HTTPOption {
+	return func(s *shipper) {
+		s.maxSpoolSize = n
+	}
+}
+
+func encodeJSONArray(records [][]byte) []byte {
+	return append(append([]byte{'['}, bytes.Join(records, []byte{','})...), ']')
+}
+
+func encodeNDJSON(records [][]byte) []byte {
+	return append(bytes.Join(records, []byte{'\n'}), '\n')
+}
+
+// errPermanent marks responses not worth retrying or spooling
+var errPermanent = errors.New("rejected by the endpoint")
+
+// shipper batches encoded records and posts them to an HTTP endpoint from a
+// background goroutine
+type shipper struct {
+	url          string
+	client       *http.Client
+	headers      http.Header
+	contentType  string
+	encode       func(records [][]byte) []byte
+	batchSize    int
+	interval     time.Duration
+	retries      int
+	backoff      time.Duration
+	spoolDir     string
+	maxSpoolSize int64
+
+	mu      sync.Mutex
+	pending [][]byte
+	closed  bool
+
+	// sendMu serializes sending and spooling
+	sendMu sync.Mutex
+	// ctx bounds the sends of the background goroutine, close cancels it
+	ctx     context.Context
+	cancel  context.CancelFunc
+	kick    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	dropped atomic.Uint64
+}
+
+func newShipper(url string, opts ...HTTPOption) (*shipper, error) {
+	s := &shipper{
+		url:          url,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		headers:      http.Header{},
+		contentType:  "application/json",
+		encode:       encodeJSONArray,
+		batchSize:    100,
+		interval:     time.Second,
+		retries:      3,
+		backoff:      500 * time.Millisecond,
+		maxSpoolSize: 100 << 20,
+		kick:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.spoolDir != "" {
+		if err := os.MkdirAll(s.spoolDir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating spool directory: %w", err)
+		}
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	go s.run()
+
+	return s, nil
+}
+
+func (s *shipper) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		case <-s.kick:
+		}
+
+		_ = s.flush(s.ctx)
+	}
+}
+
+// add queues an encoded record, pending records above ten batches are dropped
+// while the endpoint is slow. Once closed, records are spooled, or dropped
+// without a spool directory, never sent from the caller's goroutine.
+func (s *shipper) add(_ context.Context, record []byte) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+
+		if s.spoolDir == "" {
+			s.dropped.Add(1)
+			return nil
+		}
+
+		return s.spool([][]byte{record})
+	}
+
+	if len(s.pending) >= 10*s.batchSize {
+		s.mu.Unlock()
+		s.dropped.Add(1)
+		return nil
+	}
+
+	s.pending = append(s.pending, record)
+	n := len(s.pending)
+	s.mu.Unlock()
+
+	if n >= s.batchSize {
+		select {
+		case s.kick <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// flush sends the pending records, then the spooled ones if the endpoint is up
+func (s *shipper) flush(ctx context.Context) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	for {
+		s.mu.Lock()
+		batch := s.pending[:min(len(s.pending), s.batchSize)]
+		s.pending = s.pending[len(batch):]
+		s.mu.Unlock()
+
+		if len(batch) == 0 {
+			break
+		}
+
+		if err := s.send(ctx, batch, s.retries); err != nil {
+			// cancelled, e.g. by close, the records are sent or spooled later
+			if ctx.Err() != nil {
+				s.mu.Lock()
+				s.pending = append(batch[:len(batch):len(batch)], s.pending...)
+				s.mu.Unlock()
+
+				return err
+			}
+
+			if errors.Is(err, errPermanent) || s.spoolDir == "" {
+				s.dropped.Add(uint64(len(batch)))
+				return err
+			}
+
+			// the endpoint is down, the other pending records would fail too
+			s.mu.Lock()
+			batch = append(batch[:len(batch):len(batch)], s.pending...)
+			s.pending = nil
+			s.mu.Unlock()
+
+			return errors.Join(err, s.spool(batch))
+		}
+	}
+
+	return s.resend(ctx)
+}
+
+// send posts the records, retrying network errors, 429 and 5xx responses
+func (s *shipper) send(ctx context.Context, records [][]byte, retries int) error {
+	body := s.encode(records)
+
+	for attempt := 0; ; attempt++ {
+		err := s.post(ctx, body)
+		if err == nil || errors.Is(err, errPermanent) || attempt >= retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(s.backoff << attempt):
+		}
+	}
+}
+
+func (s *shipper) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPermanent, err)
+	}
+
+	for k, v := range s.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", s.contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("shipping logs: %s", resp.Status)
+	}
+
+	return fmt.Errorf("%w: %s", errPermanent, resp.Status)
+}
+
+// spool writes the records to a new file, one per line, dropping the oldest
+// files above the maximum spool size
+func (s *shipper) spool(records [][]byte) error {
+	name := filepath.Join(s.spoolDir, fmt.Sprintf("%020d.ndjson", time.Now().UnixNano()))
+
+	if err := os.WriteFile(name+".tmp", encodeNDJSON(records), 0o644); err != nil {
+		s.dropped.Add(uint64(len(records)))
+		return err
+	}
+
+	if err := os.Rename(name+".tmp", name); err != nil {
+		s.dropped.Add(uint64(len(records)))
+		return err
+	}
+
+	files := s.spooled()
+
+	var total int64
+	for i := len(files) - 1; i >= 0; i-- {
+		info, err := os.Stat(files[i])
+		if err != nil {
+			continue
+		}
+
+		total += info.Size()
+		if total > s.maxSpoolSize {
+			s.dropped.Add(uint64(countLines(files[i])))
+			os.Remove(files[i])
+		}
+	}
+
+	return nil
+}
+
+// resend sends the spooled files, oldest first, stopping at the first failure
+func (s *shipper) resend(ctx context.Context) error {
+	if s.spoolDir == "" {
+		return nil
+	}
+
+	for _, name := range s.spooled() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		records := bytes.Split(bytes.TrimSuffix(data, []byte{'\n'}), []byte{'\n'})
+		for len(records) > 0 {
+			batch := records[:min(len(records), s.batchSize)]
+
+			err := s.send(ctx, batch, 0)
+			if errors.Is(err, errPermanent) {
+				s.dropped.Add(uint64(len(batch)))
+			} else if err != nil {
+				// keep what was not sent for the next time
+				return os.WriteFile(name, encodeNDJSON(records), 0o644)
+			}
+
+			records = records[len(batch):]
+		}
+
+		os.Remove(name)
+	}
+
+	return nil
+}
+
+// spooled returns the spool files, oldest first
+func (s *shipper) spooled() []string {
+	files, _ := filepath.Glob(filepath.Join(s.spoolDir, "*.ndjson"))
+	sort.Strings(files)
+
+	return files
+}
+
+func countLines(name string) int {
+	data, _ := os.ReadFile(name)
+	return bytes.Count(data, []byte{'\n'})
+}
+
+// close stops the background goroutine, cancelling its sends, and sends the
+// pending records within ctx. Those left unsent when ctx is done are spooled,
+// or dropped without a spool directory.
+func (s *shipper) close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.done)
+	s.cancel()
+
+	select {
+	case <-s.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	err := s.flush(ctx)
+	if ctx.Err() != nil {
+		return errors.Join(err, s.spoolPending())
+	}
+
+	return err
+}
+
+// spoolPending spools the records not sent yet
+func (s *shipper) spoolPending() error {
+	s.mu.Lock()
+	records := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	if s.spoolDir == "" {
+		s.dropped.Add(uint64(len(records)))
+		return nil
+	}
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	return s.spool(records)
+}
+
+// HTTPHandler sends records as JSON to an HTTP endpoint in batches, from a
+// background goroutine. Failed requests are retried, then the batch is
+// spooled to disk with WithSpoolDir or dropped. Call Close with the logger on
+// shutdown to send the pending records.
+type HTTPHandler struct {
+	enc   *jsonEncoder
+	level slog.Leveler
+	s     *shipper
+}
+
+// NewHTTPHandler starts shipping records to url, opts are used like by
+// slog.NewJSONHandler
+func NewHTTPHandler(url string, opts *slog.HandlerOptions, hopts ...HTTPOption) (*HTTPHandler, error) {
+	s, err := newShipper(url, hopts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPHandler{
+		enc:   newJSONEncoder(opts),
+		level: handlerLevel(opts),
+		s:     s,
+	}, nil
+}
+
+func (h *HTTPHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *HTTPHandler) Handle(ctx context.Context, rec slog.Record) error {
+	record, err := h.enc.encode(ctx, rec)
+	if err != nil {
+		return err
+	}
+
+	return h.s.add(ctx, record)
+}
+
+func (h *HTTPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &HTTPHandler{enc: h.enc.withAttrs(attrs), level: h.level, s: h.s}
+}
+
+func (h *HTTPHandler) WithGroup(name string) slog.Handler {
+	return &HTTPHandler{enc: h.enc.withGroup(name), level: h.level, s: h.s}
+}
+
+// Dropped returns the number of records dropped, because the endpoint
+// rejected them or was down without a spool directory
+func (h *HTTPHandler) Dropped() uint64 {
+	return h.s.dropped.Load()
+}
+
+// Flush sends the pending records
+func (h *HTTPHandler) Flush(ctx context.Context) error {
+	return h.s.flush(ctx)
+}
+
+// Close stops the background goroutine, cancelling a send being retried,
+// and sends the pending records until ctx is done. Records left then are
+// spooled with WithSpoolDir. Records logged afterwards are spooled too, or
+// dropped without a spool directory.
+func (h *HTTPHandler) Close(ctx context.Context) error {
+	return h.s.close(ctx)
+}
@@ -0,0 +1,225 @@
+package logger_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+// collector is a stand-in log endpoint answering with status
+type collector struct {
+	status  atomic.Int32
+	mu      sync.Mutex
+	records []map[string]any
+	types   []string
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	c := &collector{}
+	c.status.Store(http.StatusOK)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := int(c.status.Load())
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.types = append(c.types, r.Header.Get("Content-Type"))
+
+		if r.Header.Get("Content-Type") == "application/x-ndjson" {
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				var rec map[string]any
+				if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+					t.Error(err)
+				}
+				c.records = append(c.records, rec)
+			}
+			return
+		}
+
+		var batch []map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Error(err)
+		}
+		c.records = append(c.records, batch...)
+	}))
+	t.Cleanup(srv.Close)
+
+	return c, srv
+}
+
+func (c *collector) received() []map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]map[string]any(nil), c.records...)
+}
+
+func TestHTTPHandler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("batches through the logger", func(t *testing.T) {
+		c, srv := newCollector(t)
+
+		h, err := logger.NewHTTPHandler(srv.URL, nil, logger.WithBatchSize(2), logger.WithFlushInterval(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		log := logger.NewLogger(logger.WithLevel("info"), logger.WithSinks(logger.Sink{Handler: h}))
+		log.Info("one")
+		log.Info("two")
+
+		assert.Eventually(t, func() bool { return len(c.received()) == 2 }, time.Second, 5*time.Millisecond)
+
+		log.Info("three", "n", 3)
+		assert.NoError(t, logger.Close(ctx, log))
+
+		records := c.received()
+		assert.Len(t, records, 3)
+		assert.Equal(t, "three", records[2]["msg"])
+		assert.Equal(t, float64(3), records[2]["n"])
+		assert.Equal(t, "application/json", c.types[0])
+	})
+
+	t.Run("ndjson with retries", func(t *testing.T) {
+		c, srv := newCollector(t)
+		c.status.Store(http.StatusServiceUnavailable)
+
+		h, err := logger.NewHTTPHandler(srv.URL, nil,
+			logger.WithNDJSON(true), logger.WithFlushInterval(time.Hour), logger.WithRetries(5, 10*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		slog.New(h).Info("retried")
+
+		time.AfterFunc(25*time.Millisecond, func() { c.status.Store(http.StatusOK) })
+		assert.NoError(t, h.Flush(ctx))
+
+		assert.Len(t, c.received(), 1)
+		assert.Equal(t, "application/x-ndjson", c.types[0])
+	})
+
+	t.Run("spooled while the endpoint is down", func(t *testing.T) {
+		c, srv := newCollector(t)
+		c.status.Store(http.StatusBadGateway)
+
+		dir := filepath.Join(t.TempDir(), "spool")
+		h, err := logger.NewHTTPHandler(srv.URL, nil,
+			logger.WithSpoolDir(dir), logger.WithFlushInterval(time.Hour), logger.WithRetries(0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		log := slog.New(h)
+		log.Info("a")
+		log.Info("b")
+		assert.Error(t, h.Flush(ctx))
+
+		files, _ := os.ReadDir(dir)
+		assert.Len(t, files, 1)
+
+		c.status.Store(http.StatusOK)
+		log.Info("c")
+		assert.NoError(t, h.Close(ctx))
+
+		files, _ = os.ReadDir(dir)
+		assert.Empty(t, files)
+
+		var msgs []any
+		for _, rec := range c.received() {
+			msgs = append(msgs, rec["msg"])
+		}
+		assert.Equal(t, []any{"c", "a", "b"}, msgs)
+		assert.Zero(t, h.Dropped())
+	})
+
+	t.Run("close honours the deadline while retrying", func(t *testing.T) {
+		c, srv := newCollector(t)
+		c.status.Store(http.StatusServiceUnavailable)
+
+		dir := t.TempDir()
+		h, err := logger.NewHTTPHandler(srv.URL, nil,
+			logger.WithSpoolDir(dir), logger.WithBatchSize(1), logger.WithRetries(10, time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		slog.New(h).Info("stuck")
+		time.Sleep(50 * time.Millisecond) // the background goroutine is backing off
+
+		closeCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err = h.Close(closeCtx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+
+		files, _ := os.ReadDir(dir)
+		assert.Len(t, files, 1)
+		assert.Zero(t, h.Dropped())
+	})
+
+	t.Run("records logged after close are not sent", func(t *testing.T) {
+		c, srv := newCollector(t)
+		c.status.Store(http.StatusServiceUnavailable)
+
+		dir := t.TempDir()
+		spooled, err := logger.NewHTTPHandler(srv.URL, nil, logger.WithSpoolDir(dir), logger.WithRetries(10, time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dropped, err := logger.NewHTTPHandler(srv.URL, nil, logger.WithRetries(10, time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NoError(t, spooled.Close(ctx))
+		assert.NoError(t, dropped.Close(ctx))
+
+		start := time.Now()
+		slog.New(spooled).Info("late")
+		slog.New(dropped).Info("late")
+		assert.Less(t, time.Since(start), time.Second)
+
+		files, _ := os.ReadDir(dir)
+		assert.Len(t, files, 1)
+		assert.Zero(t, spooled.Dropped())
+		assert.Equal(t, uint64(1), dropped.Dropped())
+	})
+
+	t.Run("rejected batches are dropped", func(t *testing.T) {
+		c, srv := newCollector(t)
+		c.status.Store(http.StatusBadRequest)
+
+		h, err := logger.NewHTTPHandler(srv.URL, nil,
+			logger.WithSpoolDir(t.TempDir()), logger.WithFlushInterval(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		slog.New(h).Info("bad")
+		assert.Error(t, h.Flush(ctx))
+		assert.Equal(t, uint64(1), h.Dropped())
+	})
+}
@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"errors"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// defaultSkipFrames are function prefixes of frames left out of traces
+var defaultSkipFrames = []string{
+	"runtime.",
+	"testing.",
+	"log/slog.",
+	"github.com/devshansharma/tools/logger.",
+}
+
+// WithSkipFrames for leaving more frames out of traces, by function prefix,
+// e.g. "github.com/gin-gonic/gin."
+func WithSkipFrames(prefixes ...string) ErrorTraceOption {
+	return func(e *errorTrace) {
+		e.skipFrames = append(e.skipFrames, prefixes...)
+	}
+}
+
+// WithAllFrames for keeping runtime and library frames in traces
+func WithAllFrames() ErrorTraceOption {
+	return func(e *errorTrace) {
+		e.skipFrames = nil
+	}
+}
+
+// WithCaptureStack for adding a `stack` attr with the call site stack to
+// records at or above level, e.g. slog.LevelError, unless one of their errors
+// already carries a trace. Only WithSkipFrames, WithAllFrames and
+// WithMaxFrames apply to the captured stack.
+func WithCaptureStack(level slog.Level, opts ...ErrorTraceOption) func(*CustomLogger) {
+	return func(cl *CustomLogger) {
+		e := newErrorTrace(opts...)
+		cl.captureStack = &captureStack{level: level, trace: e}
+	}
+}
+
+type captureStack struct {
+	level slog.Level
+	trace *errorTrace
+}
+
+// add captures the stack of the caller that logged rec
+func (c *captureStack) add(rec *slog.Record) {
+	if rec.Level < c.level || recordHasTrace(*rec) {
+		return
+	}
+
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+
+	// everything above the call site is slog and this package
+	for i, pc := range pcs {
+		if pc == rec.PC {
+			pcs = pcs[i:]
+			break
+		}
+	}
+
+	if frames := c.trace.frames(pcs); len(frames) > 0 {
+		rec.AddAttrs(slog.Any("stack", frames))
+	}
+}
+
+// recordHasTrace reports whether an error in the record attrs carries a trace
+func recordHasTrace(rec slog.Record) bool {
+	found := false
+
+	var visit func(a slog.Attr) bool
+	visit = func(a slog.Attr) bool {
+		switch a.Value.Kind() {
+		case slog.KindAny:
+			if err, ok := a.Value.Any().(error); ok && chainStack(err) != nil {
+				found = true
+			}
+		case slog.KindGroup:
+			for _, ga := range a.Value.Group() {
+				visit(ga)
+			}
+		}
+
+		return !found
+	}
+
+	rec.Attrs(visit)
+
+	return found
+}
+
+var uintptrType = reflect.TypeOf(uintptr(0))
+
+// stackOf returns the program counters carried by err itself, following the
+// go-xerrors and pkg/errors conventions: a StackTrace method returning a
+// slice of uintptr based values, or a Callers method returning []uintptr
+func stackOf(err error) []uintptr {
+	if c, ok := err.(interface{ Callers() []uintptr }); ok {
+		return c.Callers()
+	}
+
+	i := stackMethod(reflect.TypeOf(err))
+	if i < 0 {
+		return nil
+	}
+
+	m := reflect.ValueOf(err).Method(i)
+	v := m.Call(nil)[0]
+	pcs := make([]uintptr, v.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(v.Index(i).Convert(uintptrType).Uint())
+	}
+
+	return pcs
+}
+
+// stackMethods caches the index of the StackTrace method of error types,
+// -1 for the types without one
+var stackMethods sync.Map
+
+// stackMethod returns the index of a StackTrace method of t returning a
+// slice of program counters, such as the one of pkg/errors, or -1
+func stackMethod(t reflect.Type) int {
+	if i, ok := stackMethods.Load(t); ok {
+		return i.(int)
+	}
+
+	index := -1
+
+	m, ok := t.MethodByName("StackTrace")
+	if ok && m.Type.NumIn() == 1 && m.Type.NumOut() == 1 {
+		out := m.Type.Out(0)
+		if out.Kind() == reflect.Slice && out.Elem().Kind() == reflect.Uintptr {
+			index = m.Index
+		}
+	}
+
+	stackMethods.Store(t, index)
+
+	return index
+}
+
+// chainStack returns the first stack found in the chain of err
+func chainStack(err error) []uintptr {
+	for err != nil {
+		if pcs := stackOf(err); len(pcs) > 0 {
+			return pcs
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return nil
+}
+
+// frames resolves the program counters, leaving out skipped frames
+func (e *errorTrace) frames(pcs []uintptr) []stackFrame {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	var s []stackFrame
+
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+
+		if f.Function != "" && !e.skip(f.Function) {
+			s = append(s, newStackFrame(f))
+			if e.maxFrames > 0 && len(s) == e.maxFrames {
+				break
+			}
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return s
+}
+
+func (e *errorTrace) skip(function string) bool {
+	return hasAnyPrefix(function, e.skipFrames)
+}
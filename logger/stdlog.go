@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// writerSkipFrames are the frames between the code logging and Writer.Write
+var writerSkipFrames = []string{
+	"log.",
+	"fmt.",
+	"io.",
+	"runtime.",
+	"github.com/devshansharma/tools/logger.",
+	"github.com/gin-gonic/gin.",
+}
+
+// Writer turns each write into a record, for the standard log package and
+// libraries logging to an io.Writer. A level in brackets or followed by a
+// colon at the start of the line, e.g. "[WARNING]" or "ERROR:", overrides the
+// default level, and lines mentioning a panic are logged at ERROR at least.
+type Writer struct {
+	log   *slog.Logger
+	level slog.Level
+}
+
+// NewWriter returns a Writer logging to l at level by default
+func NewWriter(l *slog.Logger, level slog.Level) *Writer {
+	return &Writer{log: l, level: level}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\r\n")
+	level, msg := detectLevel(msg, w.level)
+
+	ctx := context.Background()
+	if !w.log.Enabled(ctx, level) {
+		return len(p), nil
+	}
+
+	rec := slog.NewRecord(time.Now(), level, msg, callerPC(writerSkipFrames))
+	if err := w.log.Handler().Handle(ctx, rec); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// detectLevel returns the level announced at the start of msg, and msg without it
+func detectLevel(msg string, def slog.Level) (slog.Level, string) {
+	level, found := def, false
+
+	// bracketed tokens, e.g. "[GIN-debug] [WARNING] ..."
+	for i := 0; strings.HasPrefix(msg[i:], "["); {
+		end := strings.IndexByte(msg[i:], ']')
+		if end < 0 {
+			break
+		}
+		end += i
+
+		if l, err := ParseLevel(msg[i+1 : end]); err == nil {
+			level, found = l, true
+			msg = msg[:i] + strings.TrimLeft(msg[end+1:], " ")
+			break
+		}
+
+		i = end + 1
+		for i < len(msg) && msg[i] == ' ' {
+			i++
+		}
+	}
+
+	// a prefix such as "ERROR: ..."
+	if !found {
+		if name, after, ok := strings.Cut(msg, ": "); ok && !strings.ContainsAny(name, " []") {
+			if l, err := ParseLevel(name); err == nil {
+				level, msg = l, after
+			}
+		}
+	}
+
+	if level < slog.LevelError && strings.Contains(strings.ToLower(msg), "panic") {
+		level = slog.LevelError
+	}
+
+	return level, msg
+}
+
+// callerPC returns the first caller whose function doesn't start with one of
+// the prefixes, 0 when there is none
+func callerPC(skip []string) uintptr {
+	pcs := make([]uintptr, 32)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if !hasAnyPrefix(f.Function, skip) {
+			return f.PC + 1
+		}
+
+		if !more {
+			return 0
+		}
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedirectStdLog sends the output of the standard log package to l, at level
+// by default, and returns a func restoring the previous output. Call it after
+// SetGlobal, which redirects the log package at INFO without level detection.
+func RedirectStdLog(l *slog.Logger, level slog.Level) func() {
+	out, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+
+	log.SetOutput(NewWriter(l, level))
+	log.SetFlags(0)
+	log.SetPrefix("")
+
+	return func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}
+}
+
+// NewStdLogger returns a *log.Logger writing to l, e.g. for http.Server.ErrorLog
+func NewStdLogger(l *slog.Logger, level slog.Level) *log.Logger {
+	return log.New(NewWriter(l, level), "", 0)
+}
+
+// Fatal logs msg at FATAL, flushes the logger, waiting 5s at most, and exits
+// with status 1
+func Fatal(l *slog.Logger, msg string, args ...any) {
+	ctx := context.Background()
+
+	if l.Enabled(ctx, LevelFatal) {
+		rec := slog.NewRecord(time.Now(), LevelFatal, msg, callerPC(defaultSkipFrames))
+		rec.Add(args...)
+		_ = l.Handler().Handle(ctx, rec)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_ = Close(ctx, l)
+
+	os.Exit(1)
+}
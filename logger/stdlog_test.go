@@ -0,0 +1,62 @@
+package logger_test
+
+import (
+	"log"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+	"github.com/devshansharma/tools/logger/loggertest"
+)
+
+func TestWriter(t *testing.T) {
+	rec := loggertest.NewRecorder()
+	w := logger.NewWriter(slog.New(rec), slog.LevelInfo)
+
+	for _, line := range []string{
+		"plain line\n",
+		"[GIN-debug] [WARNING] Running in \"debug\" mode\n",
+		"ERROR: connection refused\n",
+		"http: panic serving 10.0.0.1:4321: boom\n",
+		"[debug] verbose\n",
+	} {
+		_, err := w.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+
+	loggertest.AssertLogged(t, rec, slog.LevelInfo, "plain line")
+	loggertest.AssertLogged(t, rec, slog.LevelWarn, `[GIN-debug] Running in "debug" mode`)
+	loggertest.AssertLogged(t, rec, slog.LevelError, "connection refused")
+	loggertest.AssertLogged(t, rec, slog.LevelError, "http: panic serving 10.0.0.1:4321: boom")
+	loggertest.AssertLogged(t, rec, slog.LevelDebug, "verbose")
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	rec := loggertest.NewRecorder()
+	restore := logger.RedirectStdLog(slog.New(rec), slog.LevelInfo)
+
+	_, redirected := log.Writer().(*logger.Writer)
+	assert.True(t, redirected)
+
+	log.Printf("cache warmed in %dms", 12)
+	restore()
+
+	_, redirected = log.Writer().(*logger.Writer)
+	assert.False(t, redirected)
+
+	entries := rec.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "cache warmed in 12ms", entries[0].Message)
+
+	// the source is the caller of log.Printf
+	frame, _ := runtime.CallersFrames([]uintptr{entries[0].Record.PC}).Next()
+	assert.Equal(t, "stdlog_test.go", filepath.Base(frame.File))
+
+	std := logger.NewStdLogger(slog.New(rec), slog.LevelWarn)
+	std.Print("TLS handshake error")
+	loggertest.AssertLogged(t, rec, slog.LevelWarn, "TLS handshake error")
+}
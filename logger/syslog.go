@@ -0,0 +1,229 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FacilityUser is the syslog facility for user-level messages
+	FacilityUser = 1
+	// FacilityLocal0 is the first of the local use facilities, up to 23 for local7
+	FacilityLocal0 = 16
+)
+
+// SyslogOption to configure SyslogHandler
+type SyslogOption func(s *syslogWriter)
+
+// WithFacility for the syslog facility, default is FacilityUser
+func WithFacility(facility int) SyslogOption {
+	return func(s *syslogWriter) {
+		s.facility = facility
+	}
+}
+
+// WithAppName for the APP-NAME field, default is the name of the executable
+func WithAppName(name string) SyslogOption {
+	return func(s *syslogWriter) {
+		s.appName = name
+	}
+}
+
+// WithHostname for the HOSTNAME field, default is os.Hostname
+func WithHostname(name string) SyslogOption {
+	return func(s *syslogWriter) {
+		s.hostname = name
+	}
+}
+
+// syslogSeverity maps a level to a syslog severity
+func syslogSeverity(l slog.Level) int {
+	switch {
+	case l >= LevelFatal:
+		return 2 // critical
+	case l >= slog.LevelError:
+		return 3
+	case l >= slog.LevelWarn:
+		return 4
+	case l >= slog.LevelInfo:
+		return 6
+	}
+
+	return 7 // debug
+}
+
+// syslogWriter sends messages to a syslog server, shared by a SyslogHandler
+// and the handlers derived from it
+type syslogWriter struct {
+	network  string
+	addr     string
+	facility int
+	appName  string
+	hostname string
+	pid      int
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// SyslogHandler sends records to a syslog server as RFC 5424 messages, with
+// the record rendered as JSON in the MSG part. Messages are framed with octet
+// counting over TCP and Unix stream sockets, see RFC 6587.
+type SyslogHandler struct {
+	enc   *jsonEncoder
+	level slog.Leveler
+	w     *syslogWriter
+}
+
+// NewSyslogHandler connects to the syslog server on network "udp", "tcp",
+// "unix" or "unixgram", e.g. NewSyslogHandler("udp", "localhost:514", nil).
+// opts are used like by slog.NewJSONHandler.
+func NewSyslogHandler(network, addr string, opts *slog.HandlerOptions, sopts ...SyslogOption) (*SyslogHandler, error) {
+	w := &syslogWriter{
+		network:  network,
+		addr:     addr,
+		facility: FacilityUser,
+		appName:  filepath.Base(os.Args[0]),
+		pid:      os.Getpid(),
+	}
+	w.hostname, _ = os.Hostname()
+
+	for _, opt := range sopts {
+		opt(w)
+	}
+
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q", network)
+	}
+
+	if err := w.dial(); err != nil {
+		return nil, err
+	}
+
+	return &SyslogHandler{
+		enc:   newJSONEncoder(opts),
+		level: handlerLevel(opts),
+		w:     w,
+	}, nil
+}
+
+func (s *SyslogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= s.level.Level()
+}
+
+func (s *SyslogHandler) Handle(ctx context.Context, rec slog.Record) error {
+	msg, err := s.enc.encode(ctx, rec)
+	if err != nil {
+		return err
+	}
+
+	return s.w.write(rec.Level, rec.Time, msg)
+}
+
+func (s *SyslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SyslogHandler{enc: s.enc.withAttrs(attrs), level: s.level, w: s.w}
+}
+
+func (s *SyslogHandler) WithGroup(name string) slog.Handler {
+	return &SyslogHandler{enc: s.enc.withGroup(name), level: s.level, w: s.w}
+}
+
+// Close closes the connection to the syslog server
+func (s *SyslogHandler) Close(context.Context) error {
+	s.w.mu.Lock()
+	defer s.w.mu.Unlock()
+
+	if s.w.conn == nil {
+		return nil
+	}
+
+	err := s.w.conn.Close()
+	s.w.conn = nil
+
+	return err
+}
+
+func (w *syslogWriter) dial() error {
+	conn, err := net.DialTimeout(w.network, w.addr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("dialing syslog: %w", err)
+	}
+
+	w.conn = conn
+
+	return nil
+}
+
+func (w *syslogWriter) stream() bool {
+	return strings.HasPrefix(w.network, "tcp") || w.network == "unix"
+}
+
+// write sends one message, reconnecting once when the connection was lost
+func (w *syslogWriter) write(level slog.Level, t time.Time, msg []byte) error {
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s %s %d - - ",
+		w.facility*8+syslogSeverity(level),
+		t.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogField(w.hostname, 255),
+		syslogField(w.appName, 48),
+		w.pid,
+	)
+
+	frame := make([]byte, 0, len(header)+len(msg)+8)
+	if w.stream() {
+		frame = fmt.Appendf(frame, "%d ", len(header)+len(msg))
+	}
+	frame = append(append(frame, header...), msg...)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if _, err := w.conn.Write(frame); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	if err := w.dial(); err != nil {
+		return err
+	}
+
+	_, err := w.conn.Write(frame)
+
+	return err
+}
+
+// syslogField returns s as a header field: printable ASCII without spaces,
+// "-" when empty
+func syslogField(s string, limit int) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c <= ' ' || c > '~' {
+			b[i] = '_'
+		}
+	}
+
+	if len(b) > limit {
+		b = b[:limit]
+	}
+
+	if len(b) == 0 {
+		return "-"
+	}
+
+	return string(b)
+}
@@ -0,0 +1,127 @@
+package logger_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+func TestSyslogHandler(t *testing.T) {
+	t.Run("udp", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		h, err := logger.NewSyslogHandler("udp", conn.LocalAddr().String(), nil,
+			logger.WithFacility(logger.FacilityLocal0), logger.WithAppName("billing api"), logger.WithHostname("web-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		slog.New(h).With("tenant", "acme").Error("charge failed")
+
+		buf := make([]byte, 4096)
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// local0 * 8 + error
+		assert.Regexp(t, `^<131>1 \d{4}-\d\d-\d\dT\S+ web-1 billing_api \d+ - - \{.*"msg":"charge failed","tenant":"acme"\}$`, string(buf[:n]))
+	})
+
+	t.Run("tcp octet counting", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+
+		h, err := logger.NewSyslogHandler("tcp", ln.Addr().String(), &slog.HandlerOptions{Level: slog.LevelDebug})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		log := slog.New(h)
+		log.Debug("first")
+		log.Warn("second\nline")
+
+		r := bufio.NewReader(conn)
+		for _, want := range []string{`<15>1 `, `<12>1 `} {
+			size, err := r.ReadString(' ')
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			n, err := strconv.Atoi(strings.TrimSpace(size))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.True(t, strings.HasPrefix(string(msg), want), string(msg))
+		}
+	})
+
+	t.Run("unixgram", func(t *testing.T) {
+		dir, err := os.MkdirTemp("", "syslog")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "log.sock")
+		conn, err := net.ListenPacket("unixgram", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		h, err := logger.NewSyslogHandler("unixgram", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer h.Close(context.Background())
+
+		slog.New(h).Info("hello")
+
+		buf := make([]byte, 4096)
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Contains(t, string(buf[:n]), `"msg":"hello"`)
+	})
+
+	t.Run("unsupported network", func(t *testing.T) {
+		_, err := logger.NewSyslogHandler("ip", "localhost", nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TraceContext identifies the span a record was logged in, see the W3C
+// Trace Context recommendation
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// IsValid reports whether the trace and span ids are set
+func (t TraceContext) IsValid() bool {
+	return t.TraceID != [16]byte{} && t.SpanID != [8]byte{}
+}
+
+// ParseTraceparent parses a traceparent header, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func ParseTraceparent(s string) (TraceContext, error) {
+	var t TraceContext
+
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return t, fmt.Errorf("invalid traceparent %q", s)
+	}
+
+	var flags [1]byte
+	for _, f := range []struct {
+		dst []byte
+		src string
+	}{
+		{t.TraceID[:], parts[1]},
+		{t.SpanID[:], parts[2]},
+		{flags[:], parts[3]},
+	} {
+		if len(f.src) != 2*len(f.dst) || strings.ToLower(f.src) != f.src {
+			return TraceContext{}, fmt.Errorf("invalid traceparent %q", s)
+		}
+
+		if _, err := hex.Decode(f.dst, []byte(f.src)); err != nil {
+			return TraceContext{}, fmt.Errorf("invalid traceparent %q", s)
+		}
+	}
+
+	t.Flags = flags[0]
+
+	if !t.IsValid() {
+		return TraceContext{}, fmt.Errorf("invalid traceparent %q", s)
+	}
+
+	return t, nil
+}
+
+type traceKey struct{}
+
+// ContextWithTrace returns a copy of ctx carrying the trace context
+func ContextWithTrace(ctx context.Context, t TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// TraceFromContext returns the trace context set with ContextWithTrace
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+
+	t, ok := ctx.Value(traceKey{}).(TraceContext)
+
+	return t, ok && t.IsValid()
+}
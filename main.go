@@ -8,6 +8,7 @@ import (
 
 	"github.com/devshansharma/tools/crypt"
 	"github.com/devshansharma/tools/logger"
+	"github.com/devshansharma/tools/middleware"
 	"github.com/devshansharma/tools/server"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
@@ -17,13 +18,19 @@ import (
 func main() {
 	ctx := context.Background()
 
-	log := logger.New(
+	log := logger.NewLogger(
 		logger.WithJSON(true),
+		logger.WithFastJSON(true),
 		logger.WithSource(true),
 		logger.WithLevel("INFO"),
+		logger.WithHandle(logger.ContextAttrsHandle),
 		logger.WithReplaceAttr(logger.WithShortFileNameAndErrorTrace),
+		logger.WithCaptureStack(slog.LevelError),
+		logger.WithAsync(logger.WithOverflowPolicy(logger.DropBelowLevel)),
 	)
-	slog.SetDefault(log)
+	logger.SetGlobal(log)
+	logger.RedirectStdLog(log, slog.LevelInfo)
+	middleware.RedirectGin(log)
 
 	router := gin.New()
 
@@ -57,7 +64,11 @@ func main() {
 	})
 
 	srv := server.New(":8080", router,
-		server.WithServerTimeout(11),
+		server.WithServerTimeout(11*time.Second),
+		server.WithLogger(log),
+		server.WithShutdownFunc(func(ctx context.Context) error {
+			return logger.Close(ctx, log)
+		}),
 	)
 
 	slog.InfoContext(ctx, "starting server on port: 8080")
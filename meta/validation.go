@@ -0,0 +1,257 @@
+package meta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes why a single field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+	Key     string `json:"key"`
+}
+
+// FieldErrors is returned by Bind when the bound struct is invalid
+type FieldErrors []FieldError
+
+func (f FieldErrors) Error() string {
+	msgs := make([]string, len(f))
+	for i, e := range f {
+		msgs[i] = e.Message
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+var (
+	setupOnce sync.Once
+
+	messagesMu sync.RWMutex
+	// messages per rule, {field} and {param} are replaced when rendering
+	messages = map[string]string{
+		"required": "{field} is required",
+		"email":    "{field} must be a valid email address",
+		"url":      "{field} must be a valid URL",
+		"uuid":     "{field} must be a valid UUID",
+		"oneof":    "{field} must be one of [{param}]",
+		"numeric":  "{field} must be numeric",
+		"alphanum": "{field} must be alphanumeric",
+		"gt":       "{field} must be greater than {param}",
+		"gte":      "{field} must be greater than or equal to {param}",
+		"lt":       "{field} must be less than {param}",
+		"lte":      "{field} must be less than or equal to {param}",
+		"type":     "{field} has an invalid type",
+	}
+)
+
+// SetupValidator makes gin's validator report fields by the name they are
+// bound from instead of the Go field name, meant to be called once at
+// startup before Bind and Validate. The validator is global to gin, so it
+// also applies to ctx.ShouldBind and the like.
+func SetupValidator() error {
+	v := validate()
+	if v == nil {
+		return fmt.Errorf("gin validator is not a go-playground validator")
+	}
+
+	setupOnce.Do(func() {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, tag := range []string{"json", "form", "uri"} {
+				name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+				if name == "-" {
+					return ""
+				}
+
+				if name != "" {
+					return name
+				}
+			}
+
+			return f.Name
+		})
+	})
+
+	return nil
+}
+
+// validate returns gin's validator engine, nil when it is not a
+// go-playground validator
+func validate() *validator.Validate {
+	v, _ := binding.Validator.Engine().(*validator.Validate)
+	return v
+}
+
+// RegisterRule for adding a custom validation rule with its message, meant to
+// be called once at startup
+func RegisterRule(tag string, fn validator.Func, message string) error {
+	v := validate()
+	if v == nil {
+		return fmt.Errorf("gin validator is not a go-playground validator")
+	}
+
+	if err := v.RegisterValidation(tag, fn); err != nil {
+		return fmt.Errorf("failed to register rule %s: %w", tag, err)
+	}
+
+	RegisterMessage(tag, message)
+
+	return nil
+}
+
+// RegisterMessage for replacing the message of a rule, {field} and {param}
+// are replaced by the field path and the rule parameter
+func RegisterMessage(tag, message string) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+
+	messages[tag] = message
+}
+
+// Bind fills obj from the path params (`uri` tags), the query string (`form`
+// tags) and a JSON body, then validates it. Validation failures are returned
+// as FieldErrors, any other error means the request could not be decoded.
+// Field paths look like "items.0.name", see SetupValidator for the names used.
+func Bind(ctx *gin.Context, obj any) error {
+	if len(ctx.Params) > 0 {
+		params := make(map[string][]string, len(ctx.Params))
+		for _, p := range ctx.Params {
+			params[p.Key] = []string{p.Value}
+		}
+
+		if err := binding.MapFormWithTag(obj, params, "uri"); err != nil {
+			return fmt.Errorf("failed to bind path params: %s", err.Error())
+		}
+	}
+
+	if ctx.Request.URL != nil && ctx.Request.URL.RawQuery != "" {
+		if err := binding.MapFormWithTag(obj, ctx.Request.URL.Query(), "form"); err != nil {
+			return fmt.Errorf("failed to bind query: %s", err.Error())
+		}
+	}
+
+	if ctx.Request.Body != nil && ctx.Request.Body != http.NoBody && ctx.ContentType() == binding.MIMEJSON {
+		err := json.NewDecoder(ctx.Request.Body).Decode(obj)
+
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.Is(err, io.EOF):
+		case errors.As(err, &typeErr):
+			return FieldErrors{newFieldError(typeErr.Field, "type", "")}
+		case err != nil:
+			return fmt.Errorf("failed to bind body: %s", err.Error())
+		}
+	}
+
+	return Validate(obj)
+}
+
+// Validate runs the struct validation on obj, returning FieldErrors
+func Validate(obj any) error {
+	err := binding.Validator.ValidateStruct(obj)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	out := make(FieldErrors, len(verrs))
+	for i, fe := range verrs {
+		out[i] = newFieldError(fieldPath(fe), fe.Tag(), fe.Param())
+
+		if msg, ok := sizeMessage(fe); ok {
+			out[i].Message = msg
+		}
+	}
+
+	return out
+}
+
+// RenderBindError writes the error returned by Bind, 422 with the field
+// errors for validation failures and 400 otherwise
+func RenderBindError(ctx *gin.Context, err error) {
+	var ferrs FieldErrors
+	if errors.As(err, &ferrs) {
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"errors": ferrs})
+		return
+	}
+
+	ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+func newFieldError(field, rule, param string) FieldError {
+	messagesMu.RLock()
+	msg, ok := messages[rule]
+	messagesMu.RUnlock()
+
+	if !ok {
+		msg = "{field} is invalid"
+	}
+
+	return FieldError{
+		Field:   field,
+		Rule:    rule,
+		Param:   param,
+		Message: strings.NewReplacer("{field}", field, "{param}", param).Replace(msg),
+		Key:     "validation." + rule,
+	}
+}
+
+// sizeMessage words min, max and len depending on what is measured, unless a
+// message was registered for the rule
+func sizeMessage(fe validator.FieldError) (string, bool) {
+	messagesMu.RLock()
+	_, custom := messages[fe.Tag()]
+	messagesMu.RUnlock()
+
+	if custom {
+		return "", false
+	}
+
+	var bound string
+	switch fe.Tag() {
+	case "min":
+		bound = "at least "
+	case "max":
+		bound = "at most "
+	case "len":
+		bound = "exactly "
+	default:
+		return "", false
+	}
+
+	field := fieldPath(fe)
+
+	switch fe.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("%s must be %s%s characters long", field, bound, fe.Param()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("%s must have %s%s items", field, bound, fe.Param()), true
+	}
+
+	return fmt.Sprintf("%s must be %s%s", field, bound, fe.Param()), true
+}
+
+// fieldPath returns the path of a failed field in the format of the JSON
+// decoder, e.g. "items.0.name" for the namespace "createOrder.items[0].name"
+func fieldPath(fe validator.FieldError) string {
+	// the namespace starts with the struct name, which means nothing to clients
+	_, field, _ := strings.Cut(fe.Namespace(), ".")
+
+	return strings.NewReplacer("[", ".", "]", "").Replace(field)
+}
@@ -0,0 +1,96 @@
+package meta_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/meta"
+)
+
+type item struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type createOrder struct {
+	ShopID   string `uri:"shop_id" binding:"required,uuid"`
+	DryRun   bool   `form:"dry_run"`
+	Customer string `json:"customer" binding:"required,min=3"`
+	Currency string `json:"currency" binding:"required,currency_code"`
+	Items    []item `json:"items" binding:"required,min=1,dive"`
+}
+
+func TestBind(t *testing.T) {
+	assert.NoError(t, meta.SetupValidator())
+
+	err := meta.RegisterRule("currency_code", func(fl validator.FieldLevel) bool {
+		return fl.Field().String() == "EUR" || fl.Field().String() == "USD"
+	}, "{field} must be a supported currency")
+	assert.NoError(t, err)
+
+	newContext := func(body, query string) *gin.Context {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/shops/x/orders?"+query, strings.NewReader(body))
+		ctx.Request.Header.Set("Content-Type", "application/json")
+		ctx.Params = gin.Params{{Key: "shop_id", Value: "0b0f7e4e-4bb3-4a36-9b5e-8f3b0d1c2a77"}}
+		return ctx
+	}
+
+	t.Run("valid request", func(t *testing.T) {
+		var req createOrder
+		err := meta.Bind(newContext(`{"customer":"jan","currency":"EUR","items":[{"name":"book"}]}`, "dry_run=true"), &req)
+		assert.NoError(t, err)
+		assert.True(t, req.DryRun)
+		assert.Equal(t, "0b0f7e4e-4bb3-4a36-9b5e-8f3b0d1c2a77", req.ShopID)
+		assert.Equal(t, "book", req.Items[0].Name)
+	})
+
+	t.Run("field errors", func(t *testing.T) {
+		var req createOrder
+		err := meta.Bind(newContext(`{"customer":"ja","currency":"INR","items":[{"name":""}]}`, ""), &req)
+
+		var ferrs meta.FieldErrors
+		assert.ErrorAs(t, err, &ferrs)
+		assert.Equal(t, meta.FieldErrors{
+			{Field: "customer", Rule: "min", Param: "3", Message: "customer must be at least 3 characters long", Key: "validation.min"},
+			{Field: "currency", Rule: "currency_code", Message: "currency must be a supported currency", Key: "validation.currency_code"},
+			{Field: "items.0.name", Rule: "required", Message: "items.0.name is required", Key: "validation.required"},
+		}, ferrs)
+	})
+
+	t.Run("type error", func(t *testing.T) {
+		var req createOrder
+		err := meta.Bind(newContext(`{"customer":12}`, ""), &req)
+
+		var ferrs meta.FieldErrors
+		assert.ErrorAs(t, err, &ferrs)
+		assert.Equal(t, "customer", ferrs[0].Field)
+		assert.Equal(t, "validation.type", ferrs[0].Key)
+	})
+
+	t.Run("type error in an array", func(t *testing.T) {
+		var req createOrder
+		err := meta.Bind(newContext(`{"customer":"jan","currency":"EUR","items":[{"name":"book"},{"name":1}]}`, ""), &req)
+
+		var ferrs meta.FieldErrors
+		assert.ErrorAs(t, err, &ferrs)
+		assert.Equal(t, "items.1.name", ferrs[0].Field)
+		assert.Equal(t, "items.1.name has an invalid type", ferrs[0].Message)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		var req createOrder
+		err := meta.Bind(newContext(`{"customer":`, ""), &req)
+		assert.ErrorContains(t, err, "failed to bind body")
+
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		meta.RenderBindError(ctx, err)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,309 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const redacted = "[REDACTED]"
+
+// BodyLoggerOption to configure the body logger middleware
+type BodyLoggerOption func(b *bodyLogger)
+
+type bodyLogger struct {
+	log           *slog.Logger
+	maxBodySize   int
+	sampleRate    float64
+	redactHeaders map[string]bool
+	redactKeys    map[string]bool
+	redactPaths   [][]string
+}
+
+// WithBodyLogger for logging through the given logger, default is slog.Default()
+func WithBodyLogger(l *slog.Logger) BodyLoggerOption {
+	return func(b *bodyLogger) {
+		b.log = l
+	}
+}
+
+// WithMaxBodySize for the number of bytes captured per body, default is 4KB
+func WithMaxBodySize(n int) BodyLoggerOption {
+	return func(b *bodyLogger) {
+		b.maxBodySize = n
+	}
+}
+
+// WithSampleRate for the fraction of requests captured, between 0 and 1, default is 1
+func WithSampleRate(rate float64) BodyLoggerOption {
+	return func(b *bodyLogger) {
+		b.sampleRate = rate
+	}
+}
+
+// WithRedactHeaders for adding headers whose values are never logged
+func WithRedactHeaders(headers ...string) BodyLoggerOption {
+	return func(b *bodyLogger) {
+		for _, h := range headers {
+			b.redactHeaders[http.CanonicalHeaderKey(h)] = true
+		}
+	}
+}
+
+// WithRedactKeys for adding JSON and form keys redacted at any depth, matched case-insensitively
+func WithRedactKeys(keys ...string) BodyLoggerOption {
+	return func(b *bodyLogger) {
+		for _, k := range keys {
+			b.redactKeys[strings.ToLower(k)] = true
+		}
+	}
+}
+
+// WithRedactPaths for adding dot separated JSON paths to redact, e.g. "user.card.number".
+// Array elements are addressed by index, e.g. "items.0.card", and a "*"
+// segment matches any object key or array index.
+func WithRedactPaths(paths ...string) BodyLoggerOption {
+	return func(b *bodyLogger) {
+		for _, p := range paths {
+			b.redactPaths = append(b.redactPaths, strings.Split(p, "."))
+		}
+	}
+}
+
+// BodyLogger captures request and response bodies up to a size limit and logs
+// them at DEBUG with sensitive headers and fields redacted. Nothing is captured
+// when the logger is not enabled for DEBUG or the request is not sampled.
+func BodyLogger(opts ...BodyLoggerOption) gin.HandlerFunc {
+	b := &bodyLogger{
+		maxBodySize: 4 << 10,
+		sampleRate:  1,
+		redactHeaders: map[string]bool{
+			"Authorization":       true,
+			"Proxy-Authorization": true,
+			"Cookie":              true,
+			"Set-Cookie":          true,
+			"X-Api-Key":           true,
+		},
+		redactKeys: map[string]bool{
+			"password":      true,
+			"token":         true,
+			"access_token":  true,
+			"refresh_token": true,
+			"secret":        true,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return func(ctx *gin.Context) {
+		log := b.log
+		if log == nil {
+			log = slog.Default()
+		}
+
+		if !log.Enabled(ctx.Request.Context(), slog.LevelDebug) || !b.sampled() {
+			ctx.Next()
+			return
+		}
+
+		var reqBody []byte
+		var reqTruncated bool
+		if ctx.Request.Body != nil && ctx.Request.Body != http.NoBody {
+			reqBody, reqTruncated = b.captureRequest(ctx.Request)
+		}
+
+		writer := &bodyWriter{ResponseWriter: ctx.Writer, limit: b.maxBodySize}
+		ctx.Writer = writer
+
+		ctx.Next()
+
+		log.DebugContext(ctx.Request.Context(), "http payload",
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", writer.Status()),
+			slog.Group("request",
+				slog.Any("headers", b.headers(ctx.Request.Header)),
+				slog.Any("body", b.body(ctx.Request.Header.Get("Content-Type"), reqBody, reqTruncated)),
+				slog.Bool("truncated", reqTruncated),
+			),
+			slog.Group("response",
+				slog.Any("headers", b.headers(writer.Header())),
+				slog.Any("body", b.body(writer.Header().Get("Content-Type"), writer.buf.Bytes(), writer.truncated)),
+				slog.Bool("truncated", writer.truncated),
+			),
+		)
+	}
+}
+
+func (b *bodyLogger) sampled() bool {
+	if b.sampleRate >= 1 {
+		return true
+	}
+
+	return rand.Float64() < b.sampleRate
+}
+
+// captureRequest reads up to maxBodySize bytes and puts them back in front of
+// the remaining body, so the handler still sees the full payload
+func (b *bodyLogger) captureRequest(r *http.Request) ([]byte, bool) {
+	buf, err := io.ReadAll(io.LimitReader(r.Body, int64(b.maxBodySize)+1))
+	r.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(buf), r.Body),
+		Closer: r.Body,
+	}
+
+	if err != nil {
+		return nil, true
+	}
+
+	if len(buf) > b.maxBodySize {
+		return buf[:b.maxBodySize], true
+	}
+
+	return buf, false
+}
+
+func (b *bodyLogger) headers(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if b.redactHeaders[http.CanonicalHeaderKey(k)] {
+			out[k] = redacted
+			continue
+		}
+
+		out[k] = strings.Join(v, ", ")
+	}
+
+	return out
+}
+
+// body returns a loggable representation of the payload. JSON that can not be
+// parsed, for instance because it was truncated, is omitted rather than logged
+// unredacted.
+func (b *bodyLogger) body(contentType string, data []byte, truncated bool) any {
+	if len(data) == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v any
+		if truncated || json.Unmarshal(data, &v) != nil {
+			return "[unparsable JSON omitted]"
+		}
+
+		return b.redactJSON(nil, v)
+
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return "[unparsable form omitted]"
+		}
+
+		for k := range values {
+			if b.redactKeys[strings.ToLower(k)] {
+				values[k] = []string{redacted}
+			}
+		}
+
+		return values
+
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "" && utf8.Valid(data):
+		return string(data)
+	}
+
+	return "[binary body omitted]"
+}
+
+func (b *bodyLogger) redactJSON(path []string, v any) any {
+	if len(path) > 0 && b.matchPath(path) {
+		return redacted
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			if b.redactKeys[strings.ToLower(k)] {
+				val[k] = redacted
+				continue
+			}
+
+			val[k] = b.redactJSON(append(path, k), child)
+		}
+	case []any:
+		for i, child := range val {
+			val[i] = b.redactJSON(append(path, strconv.Itoa(i)), child)
+		}
+	}
+
+	return v
+}
+
+func (b *bodyLogger) matchPath(path []string) bool {
+	for _, p := range b.redactPaths {
+		if len(p) != len(path) {
+			continue
+		}
+
+		matched := true
+		for i := range p {
+			if p[i] != "*" && p[i] != path[i] {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyWriter tees the response body into a bounded buffer
+type bodyWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *bodyWriter) Write(p []byte) (int, error) {
+	w.capture(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyWriter) capture(p []byte) {
+	room := w.limit - w.buf.Len()
+	if len(p) > room {
+		p = p[:max(room, 0)]
+		w.truncated = true
+	}
+
+	w.buf.Write(p)
+}
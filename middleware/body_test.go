@@ -0,0 +1,103 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/middleware"
+)
+
+func TestBodyLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(buf *bytes.Buffer, opts ...middleware.BodyLoggerOption) *gin.Engine {
+		log := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		opts = append([]middleware.BodyLoggerOption{middleware.WithBodyLogger(log)}, opts...)
+
+		router := gin.New()
+		router.Use(middleware.BodyLogger(opts...))
+		router.POST("/login", func(ctx *gin.Context) {
+			body, _ := io.ReadAll(ctx.Request.Body)
+			ctx.JSON(http.StatusOK, gin.H{"access_token": "secret-token", "size": len(body)})
+		})
+
+		return router
+	}
+
+	t.Run("redacts headers keys and paths", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		router := newRouter(buf, middleware.WithRedactPaths("cards.*.number"))
+
+		req := httptest.NewRequest(http.MethodPost, "/login",
+			strings.NewReader(`{"user":"jan","password":"pass-123","cards":[{"number":"4111","name":"visa"}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer abc")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, buf.String(), "pass-123")
+		assert.NotContains(t, buf.String(), "Bearer abc")
+		assert.NotContains(t, buf.String(), "secret-token")
+		assert.NotContains(t, buf.String(), "4111")
+
+		var entry map[string]any
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		request := entry["request"].(map[string]any)
+		body := request["body"].(map[string]any)
+		assert.Equal(t, "jan", body["user"])
+		assert.Equal(t, "[REDACTED]", body["password"])
+		assert.Equal(t, "visa", body["cards"].([]any)[0].(map[string]any)["name"])
+	})
+
+	t.Run("redacts paths with array indices", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		router := newRouter(buf, middleware.WithRedactPaths("items.0.card"))
+
+		req := httptest.NewRequest(http.MethodPost, "/login",
+			strings.NewReader(`{"items":[{"card":"4111"},{"card":"5500"}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		var entry map[string]any
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		items := entry["request"].(map[string]any)["body"].(map[string]any)["items"].([]any)
+		assert.Equal(t, "[REDACTED]", items[0].(map[string]any)["card"])
+		assert.Equal(t, "5500", items[1].(map[string]any)["card"])
+	})
+
+	t.Run("handler sees the full body when truncated", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		router := newRouter(buf, middleware.WithMaxBodySize(8))
+
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"password":"pass-123"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Contains(t, w.Body.String(), `"size":23`)
+		assert.NotContains(t, buf.String(), "pass-123")
+		assert.Contains(t, buf.String(), `"truncated":true`)
+	})
+
+	t.Run("not sampled", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		router := newRouter(buf, middleware.WithSampleRate(0))
+
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, buf.String())
+	})
+}
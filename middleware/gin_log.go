@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+// RedirectGin sends gin's debug output, such as the registered routes, to l
+// at DEBUG and its error output at ERROR, and returns a func restoring the
+// previous writers. Call it before creating the engine: gin.Logger and
+// gin.Recovery keep the writer they were created with.
+func RedirectGin(l *slog.Logger) func() {
+	out, errOut := gin.DefaultWriter, gin.DefaultErrorWriter
+
+	gin.DefaultWriter = logger.NewWriter(l, slog.LevelDebug)
+	gin.DefaultErrorWriter = logger.NewWriter(l, slog.LevelError)
+
+	return func() {
+		gin.DefaultWriter, gin.DefaultErrorWriter = out, errOut
+	}
+}
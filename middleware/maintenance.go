@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/devshansharma/tools/crypt"
+)
+
+// MaintenanceConfig describes which routes are in maintenance and who can
+// still reach them. It can be embedded in the application configuration
+// loaded by the config package.
+type MaintenanceConfig struct {
+	// Enabled puts every route in maintenance
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Prefixes puts only the routes under these path prefixes in maintenance
+	Prefixes []string `json:"prefixes" mapstructure:"prefixes"`
+	// RetryAfter in seconds, sent in the Retry-After header, default is 60
+	RetryAfter int `json:"retry_after" mapstructure:"retry_after"`
+	// AllowIPs are IPs or CIDRs bypassing maintenance
+	AllowIPs []string `json:"allow_ips" mapstructure:"allow_ips"`
+	// AllowSubjects are JWT subjects bypassing maintenance
+	AllowSubjects []string `json:"allow_subjects" mapstructure:"allow_subjects"`
+}
+
+// MaintenanceOption to configure Maintenance
+type MaintenanceOption func(m *Maintenance)
+
+// Maintenance is a runtime switchable maintenance mode, answering 503 for the
+// routes in maintenance
+type Maintenance struct {
+	subject func(r *http.Request) string
+
+	mu       sync.RWMutex
+	cfg      MaintenanceConfig
+	nets     []*net.IPNet
+	subjects map[string]bool
+}
+
+// WithSubjectFunc for extracting the caller subject matched against
+// AllowSubjects, see JWTSubject
+func WithSubjectFunc(f func(r *http.Request) string) MaintenanceOption {
+	return func(m *Maintenance) {
+		m.subject = f
+	}
+}
+
+// NewMaintenance to create a maintenance mode, disabled until Set is called
+func NewMaintenance(opts ...MaintenanceOption) *Maintenance {
+	m := &Maintenance{}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Set replaces the maintenance configuration, it is safe to call while serving
+func (m *Maintenance) Set(cfg MaintenanceConfig) error {
+	var nets []*net.IPNet
+	for _, s := range cfg.AllowIPs {
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return fmt.Errorf("invalid allowed ip %q: %w", s, err)
+		}
+
+		nets = append(nets, n)
+	}
+
+	subjects := make(map[string]bool, len(cfg.AllowSubjects))
+	for _, s := range cfg.AllowSubjects {
+		subjects[s] = true
+	}
+
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = 60
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cfg = cfg
+	m.nets = nets
+	m.subjects = subjects
+
+	return nil
+}
+
+// Config returns the current maintenance configuration
+func (m *Maintenance) Config() MaintenanceConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.cfg
+}
+
+// Middleware answers 503 with Retry-After for routes in maintenance, unless
+// the caller is allowed. The client IP is taken from RemoteAddr, so put a
+// proxy aware middleware in front of it when running behind a load balancer.
+func (m *Maintenance) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			retryAfter, down := m.check(r)
+			if !down {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "service under maintenance"})
+		})
+	}
+}
+
+// AdminHandler returns the configuration on GET and replaces it with the JSON
+// body on PUT. Protect it like any other admin endpoint.
+func (m *Maintenance) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var cfg MaintenanceConfig
+			if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+				http.Error(w, fmt.Sprintf("invalid maintenance config: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+
+			if err := m.Set(cfg); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(m.Config())
+	})
+}
+
+func (m *Maintenance) check(r *http.Request) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.cfg.Enabled && !matchPrefix(r.URL.Path, m.cfg.Prefixes) {
+		return 0, false
+	}
+
+	if ip := net.ParseIP(remoteHost(r)); ip != nil {
+		for _, n := range m.nets {
+			if n.Contains(ip) {
+				return 0, false
+			}
+		}
+	}
+
+	if m.subject != nil && len(m.subjects) > 0 {
+		if sub := m.subject(r); sub != "" && m.subjects[sub] {
+			return 0, false
+		}
+	}
+
+	return m.cfg.RetryAfter, true
+}
+
+// JWTSubject returns a subject func reading the bearer token from the
+// Authorization header, verified with crypt.ParseAndVerifyToken
+func JWTSubject(publicKey *ecdsa.PublicKey, issuer, audience string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			return ""
+		}
+
+		claims, err := crypt.ParseAndVerifyToken(token, publicKey, issuer, audience)
+		if err != nil {
+			return ""
+		}
+
+		sub, _ := claims["sub"].(string)
+		return sub
+	}
+}
+
+func matchPrefix(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		p = strings.TrimSuffix(p, "/")
+		if p == "" || path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
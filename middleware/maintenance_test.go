@@ -0,0 +1,92 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/crypt"
+	"github.com/devshansharma/tools/middleware"
+)
+
+func TestMaintenance(t *testing.T) {
+	privateKey, err := crypt.GenerateES512PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := middleware.NewMaintenance(
+		middleware.WithSubjectFunc(middleware.JWTSubject(&privateKey.PublicKey, "example.com", "my-app")),
+	)
+	h := m.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(path, remoteAddr, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("/orders", "10.0.0.1:1234", "").Code)
+	})
+
+	t.Run("per prefix", func(t *testing.T) {
+		assert.NoError(t, m.Set(middleware.MaintenanceConfig{Prefixes: []string{"/orders/"}, RetryAfter: 120}))
+
+		w := serve("/orders/1", "10.0.0.1:1234", "")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "120", w.Header().Get("Retry-After"))
+		assert.Equal(t, http.StatusServiceUnavailable, serve("/orders", "10.0.0.1:1234", "").Code)
+		assert.Equal(t, http.StatusOK, serve("/ordersx", "10.0.0.1:1234", "").Code)
+		assert.Equal(t, http.StatusOK, serve("/users", "10.0.0.1:1234", "").Code)
+	})
+
+	t.Run("global with allowlist", func(t *testing.T) {
+		assert.NoError(t, m.Set(middleware.MaintenanceConfig{
+			Enabled:       true,
+			AllowIPs:      []string{"192.168.0.0/16", "10.0.0.2"},
+			AllowSubjects: []string{"admin"},
+		}))
+
+		token, err := crypt.CreateAccessToken(privateKey, jwt.MapClaims{
+			"iss": "example.com",
+			"aud": "my-app",
+			"sub": "admin",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "60", serve("/users", "10.0.0.1:1234", "").Header().Get("Retry-After"))
+		assert.Equal(t, http.StatusOK, serve("/users", "10.0.0.2:1234", "").Code)
+		assert.Equal(t, http.StatusOK, serve("/users", "192.168.4.4:1234", "").Code)
+		assert.Equal(t, http.StatusOK, serve("/users", "10.0.0.1:1234", token).Code)
+		assert.Equal(t, http.StatusServiceUnavailable, serve("/users", "10.0.0.1:1234", "invalid").Code)
+	})
+
+	t.Run("admin handler", func(t *testing.T) {
+		admin := m.AdminHandler()
+
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(`{"enabled":false}`)))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.False(t, m.Config().Enabled)
+		assert.Equal(t, http.StatusOK, serve("/users", "10.0.0.1:1234", "").Code)
+
+		w = httptest.NewRecorder()
+		admin.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(`{"allow_ips":["nope"]}`)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
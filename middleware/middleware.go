@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware is a standard net/http middleware, usable with any http.Handler
+// and with gin through Gin
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with the middlewares, the first one being the outermost
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
+}
+
+// Gin adapts a net/http middleware to a gin.HandlerFunc. The rest of the gin
+// chain runs inside the middleware, with the request and writer it passes on.
+// The chain is aborted when the middleware does not call the next handler or
+// the next handler did not return normally.
+func Gin(mw Middleware) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		original := ctx.Writer
+		completed := false
+
+		mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx.Request = r
+			if w != http.ResponseWriter(original) {
+				ctx.Writer = &ginWriter{ResponseWriter: original, w: w}
+			}
+
+			ctx.Next()
+			completed = true
+		})).ServeHTTP(original, ctx.Request)
+
+		ctx.Writer = original
+		if !completed {
+			ctx.Abort()
+		}
+	}
+}
+
+// ginWriter routes writes through the writer a middleware passed on, while
+// status and size bookkeeping stays with gin's own writer underneath it
+type ginWriter struct {
+	gin.ResponseWriter
+	w http.ResponseWriter
+}
+
+func (g *ginWriter) Header() http.Header {
+	return g.w.Header()
+}
+
+func (g *ginWriter) WriteHeader(code int) {
+	g.w.WriteHeader(code)
+}
+
+func (g *ginWriter) Write(p []byte) (int, error) {
+	return g.w.Write(p)
+}
+
+func (g *ginWriter) WriteString(s string) (int, error) {
+	return g.w.Write([]byte(s))
+}
+
+func (g *ginWriter) Flush() {
+	_ = http.NewResponseController(g.w).Flush()
+}
+
+func (g *ginWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(g.w).Hijack()
+}
+
+// responseRecorder keeps track of the status and size written to a response
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(p)
+	r.size += n
+	return n, err
+}
+
+// Unwrap for http.ResponseController to reach Flush and Hijack
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,182 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/logger"
+	"github.com/devshansharma/tools/logger/loggertest"
+	"github.com/devshansharma/tools/middleware"
+)
+
+func TestMiddlewares(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("net/http chain", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := slog.New(slog.NewJSONHandler(buf, nil))
+
+		var requestID string
+		mux := http.NewServeMux()
+		mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+			requestID = middleware.RequestIDFromContext(r.Context())
+			w.WriteHeader(http.StatusCreated)
+		})
+		mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+
+		h := middleware.Chain(mux,
+			middleware.RequestID(),
+			middleware.AccessLog(log),
+			middleware.Recovery(log),
+		)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NotEmpty(t, requestID)
+		assert.Equal(t, requestID, w.Header().Get(middleware.RequestIDHeader))
+
+		var entry map[string]any
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, float64(http.StatusCreated), entry["status"])
+		assert.Equal(t, requestID, entry["request_id"])
+
+		buf.Reset()
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, buf.String(), "panic recovered")
+	})
+
+	t.Run("request id with context attrs", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(
+			logger.WithWriter(buf),
+			logger.WithJSON(true),
+			logger.WithLevel("info"),
+			logger.WithHandle(logger.ContextAttrsHandle),
+		)
+
+		h := middleware.Chain(http.NotFoundHandler(), middleware.RequestID(), middleware.AccessLog(log))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, 1, strings.Count(buf.String(), `"request_id"`))
+		assert.Contains(t, buf.String(), `"request_id":"`+w.Header().Get(middleware.RequestIDHeader)+`"`)
+	})
+
+	t.Run("request id from header", func(t *testing.T) {
+		h := middleware.RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(middleware.RequestIDHeader, "abc-123")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, "abc-123", w.Header().Get(middleware.RequestIDHeader))
+
+		req.Header.Set(middleware.RequestIDHeader, "bad id\nwith newline")
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.NotEqual(t, "bad id\nwith newline", w.Header().Get(middleware.RequestIDHeader))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		h := middleware.Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("gin adapter", func(t *testing.T) {
+		log, rec := loggertest.New(t)
+
+		after := false
+		router := gin.New()
+		router.Use(
+			middleware.Gin(middleware.RequestID()),
+			middleware.Gin(middleware.AccessLog(log)),
+			middleware.Gin(middleware.Recovery(log)),
+		)
+		router.GET("/missing", func(ctx *gin.Context) {
+			ctx.JSON(http.StatusNotFound, gin.H{"id": middleware.RequestIDFromContext(ctx.Request.Context())})
+		})
+		router.GET("/panic", func(ctx *gin.Context) {
+			panic("boom")
+		}, func(ctx *gin.Context) {
+			after = true
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), w.Header().Get(middleware.RequestIDHeader))
+		loggertest.AssertLogged(t, rec, slog.LevelWarn, "http request", "status", http.StatusNotFound)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.False(t, after)
+		loggertest.AssertLogged(t, rec, slog.LevelError, "panic recovered")
+		loggertest.AssertLogged(t, rec, slog.LevelError, "http request", "status", http.StatusInternalServerError)
+	})
+}
+
+func TestRedirectGin(t *testing.T) {
+	log, rec := loggertest.New(t)
+	restore := middleware.RedirectGin(log)
+	defer restore()
+
+	gin.SetMode(gin.DebugMode)
+	defer gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/health", func(ctx *gin.Context) {})
+
+	logged := func(level slog.Level, substr string) bool {
+		for _, e := range rec.Entries() {
+			if e.Level == level && strings.Contains(e.Message, substr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	assert.True(t, logged(slog.LevelWarn, `[GIN-debug] Running in "debug" mode`))
+	assert.True(t, logged(slog.LevelDebug, "GET    /health"))
+}
+
+func TestTraceContext(t *testing.T) {
+	var (
+		trace logger.TraceContext
+		ok    bool
+	)
+
+	h := middleware.TraceContext()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace, ok = logger.TraceFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, ok)
+	assert.Equal(t, byte(0x4b), trace.TraceID[0])
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.TraceparentHeader, "garbage")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, ok)
+}
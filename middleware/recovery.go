@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recovery recovers from panics in the next handler, logs them with the stack
+// and responds with 500 if nothing was written yet. http.ErrAbortHandler is
+// re-panicked so the server can abort the response as intended.
+// A nil logger means slog.Default().
+func Recovery(l *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := newResponseRecorder(w)
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				log := l
+				if log == nil {
+					log = slog.Default()
+				}
+
+				log.ErrorContext(r.Context(), "panic recovered",
+					slog.String("panic", fmt.Sprint(v)),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("request_id", RequestIDFromContext(r.Context())),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if !rec.wroteHeader {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
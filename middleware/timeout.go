@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Timeout bounds the request context by d. Handlers are expected to honour
+// the context; when they return after the deadline without writing anything
+// the middleware responds with 503. Unlike http.TimeoutHandler the handler is
+// never left running in another goroutine, which keeps it safe to use with gin.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			rec := newResponseRecorder(w)
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			if !rec.wroteHeader && ctx.Err() == context.DeadlineExceeded {
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
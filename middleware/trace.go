@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/devshansharma/tools/logger"
+)
+
+// TraceparentHeader carries the W3C trace context of the request
+const TraceparentHeader = "traceparent"
+
+// TraceContext stores the trace context of a well formed traceparent header
+// in the request context, so that records logged with it are correlated to
+// the trace, see logger.TraceFromContext and logger.OTLPHandler.
+func TraceContext() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t, err := logger.ParseTraceparent(r.Header.Get(TraceparentHeader))
+			if err == nil {
+				r = r.WithContext(logger.ContextWithTrace(r.Context(), t))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
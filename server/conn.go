@@ -0,0 +1,164 @@
+package server
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// abuseLogInterval limits how often a rejected client is logged
+const abuseLogInterval = time.Minute
+
+// ConnStats is a snapshot of the connections tracked by ConnLimiter
+type ConnStats struct {
+	Active   int            `json:"active"`
+	Rejected uint64         `json:"rejected"`
+	PerIP    map[string]int `json:"per_ip"`
+}
+
+// ConnLimiter tracks open connections per remote IP through
+// http.Server.ConnState and closes new connections over the global or per IP
+// limit. A limit of 0 means unlimited.
+type ConnLimiter struct {
+	maxConns      int
+	maxConnsPerIP int
+	rejected      atomic.Uint64
+
+	mu         sync.Mutex
+	conns      map[net.Conn]string
+	perIP      map[string]int
+	lastLogged map[string]time.Time
+	log        *slog.Logger
+}
+
+// ConnLimiterOption to configure a ConnLimiter
+type ConnLimiterOption func(*ConnLimiter)
+
+// WithLimiterLogger for the rejected clients, default is slog.Default()
+func WithLimiterLogger(log *slog.Logger) ConnLimiterOption {
+	return func(l *ConnLimiter) {
+		l.log = log
+	}
+}
+
+// NewConnLimiter to create a connection limiter
+func NewConnLimiter(maxConns, maxConnsPerIP int, opts ...ConnLimiterOption) *ConnLimiter {
+	l := &ConnLimiter{
+		maxConns:      maxConns,
+		maxConnsPerIP: maxConnsPerIP,
+		conns:         make(map[net.Conn]string),
+		perIP:         make(map[string]int),
+		lastLogged:    make(map[string]time.Time),
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// ConnState is meant to be set as http.Server.ConnState
+func (l *ConnLimiter) ConnState(c net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		if !l.add(c) {
+			c.Close()
+		}
+	case http.StateHijacked, http.StateClosed:
+		l.remove(c)
+	}
+}
+
+// Stats returns the current connection counts
+func (l *ConnLimiter) Stats() ConnStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	perIP := make(map[string]int, len(l.perIP))
+	for ip, n := range l.perIP {
+		perIP[ip] = n
+	}
+
+	return ConnStats{
+		Active:   len(l.conns),
+		Rejected: l.rejected.Load(),
+		PerIP:    perIP,
+	}
+}
+
+func (l *ConnLimiter) add(c net.Conn) bool {
+	ip := remoteIP(c)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	overGlobal := l.maxConns > 0 && len(l.conns) >= l.maxConns
+	overIP := l.maxConnsPerIP > 0 && l.perIP[ip] >= l.maxConnsPerIP
+	if overGlobal || overIP {
+		l.rejected.Add(1)
+		l.logRejected(ip, overGlobal)
+		return false
+	}
+
+	l.conns[c] = ip
+	l.perIP[ip]++
+	return true
+}
+
+func (l *ConnLimiter) remove(c net.Conn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ip, ok := l.conns[c]
+	if !ok {
+		return
+	}
+
+	delete(l.conns, c)
+	l.perIP[ip]--
+	if l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+	}
+}
+
+// logRejected logs a rejected client at most once per abuseLogInterval, must
+// be called with mu held
+func (l *ConnLimiter) logRejected(ip string, global bool) {
+	now := time.Now()
+	if now.Sub(l.lastLogged[ip]) < abuseLogInterval {
+		return
+	}
+
+	for k, t := range l.lastLogged {
+		if now.Sub(t) >= abuseLogInterval {
+			delete(l.lastLogged, k)
+		}
+	}
+	l.lastLogged[ip] = now
+
+	log := l.log
+	if log == nil {
+		log = slog.Default()
+	}
+
+	log.Warn("connection limit reached, rejecting client",
+		slog.String("remote_ip", ip),
+		slog.Int("ip_conns", l.perIP[ip]),
+		slog.Int("active_conns", len(l.conns)),
+		slog.Bool("global_limit", global),
+	)
+}
+
+func remoteIP(c net.Conn) string {
+	addr := c.RemoteAddr().String()
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
@@ -0,0 +1,61 @@
+package server_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devshansharma/tools/server"
+)
+
+func TestConnLimiter(t *testing.T) {
+	logs := &bytes.Buffer{}
+	limiter := server.NewConnLimiter(0, 2,
+		server.WithLimiterLogger(slog.New(slog.NewTextHandler(logs, nil))))
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ConnState = limiter.ConnState
+	srv.Start()
+	defer srv.Close()
+
+	addr := srv.Listener.Addr().String()
+
+	var conns []net.Conn
+	for i := 0; i < 2; i++ {
+		c, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		conns = append(conns, c)
+	}
+
+	assert.Eventually(t, func() bool {
+		return limiter.Stats().Active == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, limiter.Stats().PerIP["127.0.0.1"])
+
+	rejected, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rejected.Close()
+
+	rejected.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = rejected.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, uint64(1), limiter.Stats().Rejected)
+	assert.Contains(t, logs.String(), `msg="connection limit reached, rejecting client" remote_ip=127.0.0.1`)
+
+	conns[0].Close()
+	assert.Eventually(t, func() bool {
+		return limiter.Stats().Active == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -2,13 +2,14 @@ package server
 
 import (
 	"context"
-	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+
+	"github.com/devshansharma/tools/logger"
 )
 
 type ConfigOption func(s *Server)
@@ -25,10 +26,20 @@ type Server struct {
 	writeTimeout      time.Duration
 	idelTimeout       time.Duration
 	serverTimeout     time.Duration
+	maxConns          int
+	maxConnsPerIP     int
+	conns             *ConnLimiter
+	shutdownFuncs     []func(ctx context.Context) error
+	log               *slog.Logger
 }
 
 // Run starts the server and blocks
 func (s *Server) Run(ctx context.Context) {
+	log := s.log
+	if log == nil {
+		log = slog.Default()
+	}
+
 	srv := &http.Server{
 		Addr:              s.addr,
 		Handler:           s.handler,
@@ -36,6 +47,8 @@ func (s *Server) Run(ctx context.Context) {
 		WriteTimeout:      s.writeTimeout,
 		IdleTimeout:       s.idelTimeout,
 		ReadHeaderTimeout: s.readHeaderTimeout,
+		ConnState:         s.conns.ConnState,
+		ErrorLog:          logger.NewStdLogger(log, slog.LevelWarn),
 	}
 
 	go func() {
@@ -47,7 +60,7 @@ func (s *Server) Run(ctx context.Context) {
 		}
 
 		if err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
+			logger.Fatal(log, "listen", "addr", s.addr, "error", err)
 		}
 	}()
 
@@ -58,17 +71,28 @@ func (s *Server) Run(ctx context.Context) {
 	signal.Notify(quit, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
 	<-quit
-	slog.WarnContext(ctx, "Shutdown Server ...")
+	log.WarnContext(ctx, "Shutdown Server ...")
 
 	ctx, cancel := context.WithTimeout(ctx, s.serverTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		slog.ErrorContext(ctx, "Server Shutdown:", err)
+		log.ErrorContext(ctx, "Server Shutdown:", "error", err)
+	}
+
+	for _, f := range s.shutdownFuncs {
+		if err := f(ctx); err != nil {
+			log.ErrorContext(ctx, "Shutdown func:", "error", err)
+		}
 	}
 
 	<-ctx.Done()
-	slog.WarnContext(ctx, "Server exiting")
+	log.WarnContext(ctx, "Server exiting")
+}
+
+// ConnStats returns the open connection counts, total and per remote IP
+func (s *Server) ConnStats() ConnStats {
+	return s.conns.Stats()
 }
 
 // WithServerTimeout for server timeout
@@ -127,6 +151,36 @@ func WithKeyFile(keyFile string) ConfigOption {
 	}
 }
 
+// WithMaxConns for limiting the number of open connections, default is 0 (unlimited)
+func WithMaxConns(n int) ConfigOption {
+	return func(srv *Server) {
+		srv.maxConns = n
+	}
+}
+
+// WithMaxConnsPerIP for limiting the number of open connections per remote IP, default is 0 (unlimited)
+func WithMaxConnsPerIP(n int) ConfigOption {
+	return func(srv *Server) {
+		srv.maxConnsPerIP = n
+	}
+}
+
+// WithShutdownFunc for running f once the server stopped serving, within the
+// server timeout, e.g. logger.Close to flush buffered logs
+func WithShutdownFunc(f func(ctx context.Context) error) ConfigOption {
+	return func(srv *Server) {
+		srv.shutdownFuncs = append(srv.shutdownFuncs, f)
+	}
+}
+
+// WithLogger for the server logs, the rejected connections and the errors of
+// http.Server, logged at WARN, default is slog.Default()
+func WithLogger(l *slog.Logger) ConfigOption {
+	return func(srv *Server) {
+		srv.log = l
+	}
+}
+
 // New to create a new server with configuration options
 func New(addr string, handler http.Handler, opts ...ConfigOption) *Server {
 	srv := &Server{
@@ -143,5 +197,11 @@ func New(addr string, handler http.Handler, opts ...ConfigOption) *Server {
 		opt(srv)
 	}
 
+	var limiterOpts []ConnLimiterOption
+	if srv.log != nil {
+		limiterOpts = append(limiterOpts, WithLimiterLogger(srv.log))
+	}
+	srv.conns = NewConnLimiter(srv.maxConns, srv.maxConnsPerIP, limiterOpts...)
+
 	return srv
 }